@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var canaryVisibilityBuckets = prometheus.ExponentialBuckets(0.1, 2, 10)
+
+// CanaryCollector periodically writes a canary document to a collection and
+// measures how long it takes before it can be read back. Canaries are written
+// in the background, independently from the scrapes.
+type CanaryCollector struct {
+	visibility prometheus.Histogram
+	failures   *prometheus.CounterVec
+
+	client      http.Client
+	solrBaseURL string
+	canary      CanaryConfig
+}
+
+// NewCanaryCollector returns a new Collector exposing the canary document visibility latency.
+func NewCanaryCollector(client http.Client, solrBaseURL string, canary CanaryConfig) (*CanaryCollector, error) {
+	return &CanaryCollector{
+		visibility: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   "canary",
+			Name:        "visibility_seconds",
+			Help:        "Time elapsed between the write of a canary document and its visibility.",
+			Buckets:     canaryVisibilityBuckets,
+			ConstLabels: prometheus.Labels{"collection": canary.Collection},
+		}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "canary",
+			Name:        "failures_total",
+			Help:        "Number of failed canary runs by reason.",
+			ConstLabels: prometheus.Labels{"collection": canary.Collection},
+		}, []string{"reason"}),
+		client:      client,
+		solrBaseURL: solrBaseURL,
+		canary:      canary,
+	}, nil
+}
+
+// Run writes a canary document every interval, it never returns.
+func (c *CanaryCollector) Run() {
+	ticker := time.NewTicker(time.Duration(c.canary.Interval))
+	defer ticker.Stop()
+
+	for {
+		if err := c.run(); err != nil {
+			log.Warnf("Canary run failed: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// run writes a canary document, waits for it to be visible and deletes it.
+func (c *CanaryCollector) run() error {
+	updateURL := fmt.Sprintf("%s/%s/update?wt=json", c.solrBaseURL, c.canary.Collection)
+	start := time.Now()
+	id := fmt.Sprintf("%s%d", c.canary.IDPrefix, start.UnixNano())
+
+	// Deleted by id, the other exporters may share the prefix and still be
+	// polling their own canary. The leftovers of failed deletions are only
+	// deleted once older than the timeout.
+	defer func() {
+		deleteByID := map[string]interface{}{"delete": map[string]string{"id": id}}
+		deleteByQuery := map[string]interface{}{"delete": map[string]string{"query": c.leftoversQuery()}}
+		for _, deletion := range []interface{}{deleteByID, deleteByQuery} {
+			if err := postJSON(c.client, updateURL, deletion, &UpdateResponse{}); err != nil {
+				log.Warnf("Failed to delete canary documents: %v", err)
+				c.failures.WithLabelValues("cleanup").Inc()
+				return
+			}
+		}
+	}()
+
+	doc := map[string]string{
+		"id":                    id,
+		c.canary.TimestampField: start.UTC().Format(time.RFC3339Nano),
+	}
+	if err := postJSON(c.client, updateURL, []map[string]string{doc}, &UpdateResponse{}); err != nil {
+		c.failures.WithLabelValues("write").Inc()
+		return fmt.Errorf("Error while writing canary document: %v", err)
+	}
+
+	deadline := start.Add(time.Duration(c.canary.Timeout))
+	for {
+		visible, err := c.isVisible(id)
+		if err != nil {
+			c.failures.WithLabelValues("read").Inc()
+			return fmt.Errorf("Error while reading canary document: %v", err)
+		}
+		if visible {
+			c.visibility.Observe(time.Since(start).Seconds())
+			return nil
+		}
+		if time.Now().After(deadline) {
+			c.failures.WithLabelValues("timeout").Inc()
+			return fmt.Errorf("Canary document %s not visible after %v", id, time.Duration(c.canary.Timeout))
+		}
+		time.Sleep(time.Duration(c.canary.PollInterval))
+	}
+}
+
+// leftoversQuery returns the query matching the canary documents written more
+// than a timeout ago.
+func (c *CanaryCollector) leftoversQuery() string {
+	return fmt.Sprintf("id:%s* AND %s:[* TO NOW-%dMILLIS]",
+		escapeQueryChars(c.canary.IDPrefix), c.canary.TimestampField, time.Duration(c.canary.Timeout)/time.Millisecond)
+}
+
+// escapeQueryChars escapes the characters having a meaning in the lucene query syntax.
+func escapeQueryChars(s string) string {
+	var escaped bytes.Buffer
+	for _, r := range s {
+		if strings.ContainsRune(`\+-!():^[]"{}~*?|&;/ `, r) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// isVisible reports whether the canary document can be read back.
+func (c *CanaryCollector) isVisible(id string) (bool, error) {
+	if c.canary.RealTimeGet {
+		response := &RealTimeGetResponse{}
+		getURL := fmt.Sprintf("%s/%s/get?wt=json&id=%s", c.solrBaseURL, c.canary.Collection, url.QueryEscape(id))
+		if err := getJSON(c.client, getURL, response); err != nil {
+			return false, err
+		}
+		return response.Doc != nil, nil
+	}
+
+	params := url.Values{}
+	params.Set("q", fmt.Sprintf("id:%q", id))
+	params.Set("rows", "0")
+	response := &QueryResponse{}
+	if err := getJSON(c.client, queryURL(c.solrBaseURL, c.canary.Collection, "/select", params), response); err != nil {
+		return false, err
+	}
+	return response.Response.NumFound > 0, nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *CanaryCollector) Collect(ch chan<- prometheus.Metric) {
+	c.visibility.Collect(ch)
+	c.failures.Collect(ch)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *CanaryCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.visibility.Describe(ch)
+	c.failures.Describe(ch)
+}
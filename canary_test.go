@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startFakeSolrCollection starts a solr collection only exposing documents once
+// they have been read visibleAfter times.
+func startFakeSolrCollection(t *testing.T, visibleAfter int) (*httptest.Server, *[]string) {
+	var mutex sync.Mutex
+	reads := 0
+	updates := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/solr/orders/update":
+			var update interface{}
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Errorf("decoding update: %v", err)
+			}
+			content, _ := json.Marshal(update)
+			updates = append(updates, string(content))
+			fmt.Fprintf(w, `{"responseHeader": {"status": 0, "QTime": 1}}`)
+		case "/solr/orders/get":
+			reads++
+			if reads < visibleAfter {
+				fmt.Fprintf(w, `{"doc": null}`)
+				return
+			}
+			fmt.Fprintf(w, `{"doc": {"id": %q}}`, r.URL.Query().Get("id"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return server, &updates
+}
+
+func TestCanaryCollector(t *testing.T) {
+	server, updates := startFakeSolrCollection(t, 3)
+	defer server.Close()
+
+	config, err := parseConfig([]byte(`{"canary": {"collection": "orders", "realtime_get": true, "poll_interval": "1ms"}}`))
+	if err != nil {
+		t.Fatalf("parsing config: %v", err)
+	}
+	collector, err := NewCanaryCollector(*http.DefaultClient, server.URL+"/solr", *config.Canary)
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	if err := collector.run(); err != nil {
+		t.Fatalf("canary run failed: %v", err)
+	}
+	if len(*updates) != 3 {
+		t.Fatalf("got %d updates, want a write and two deletes: %v", len(*updates), *updates)
+	}
+	var docs []map[string]string
+	json.Unmarshal([]byte((*updates)[0]), &docs)
+	if len(docs) != 1 || docs[0]["canary_timestamp_dt"] == "" {
+		t.Errorf("unexpected canary document %v", (*updates)[0])
+	}
+	if want := fmt.Sprintf(`{"delete":{"id":%q}}`, docs[0]["id"]); (*updates)[1] != want {
+		t.Errorf("delete = %s, want %s", (*updates)[1], want)
+	}
+	if want := `{"delete":{"query":"id:solr\\-exporter\\-canary\\-* AND canary_timestamp_dt:[* TO NOW-30000MILLIS]"}}`; (*updates)[2] != want {
+		t.Errorf("delete leftovers = %s, want %s", (*updates)[2], want)
+	}
+
+	metrics := collectMetrics(collector)
+	if count := metrics["solr_canary_visibility_seconds{collection=orders}"].GetHistogram().GetSampleCount(); count != 1 {
+		t.Errorf("visibility sample count = %d, want 1", count)
+	}
+}
+
+func TestEscapeQueryChars(t *testing.T) {
+	tests := map[string]string{
+		"canary-":      `canary\-`,
+		"exporter:1/a": `exporter\:1\/a`,
+		"plain_prefix": "plain_prefix",
+	}
+	for s, want := range tests {
+		if got := escapeQueryChars(s); got != want {
+			t.Errorf("escapeQueryChars(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestCanaryCollectorTimeout(t *testing.T) {
+	server, _ := startFakeSolrCollection(t, 1000)
+	defer server.Close()
+
+	collector, err := NewCanaryCollector(*http.DefaultClient, server.URL+"/solr", CanaryConfig{
+		Collection:     "orders",
+		IDPrefix:       "canary-",
+		TimestampField: "timestamp_dt",
+		RealTimeGet:    true,
+		Timeout:        Duration(20 * time.Millisecond),
+		PollInterval:   Duration(time.Millisecond),
+	})
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	if err := collector.run(); err == nil {
+		t.Fatalf("canary run succeeded, want a timeout")
+	}
+	metrics := collectMetrics(collector)
+	if failures := metrics["solr_canary_failures_total{collection=orders,reason=timeout}"].GetCounter().GetValue(); failures != 1 {
+		t.Errorf("timeout failures = %v, want 1", failures)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+
+	"github.com/blang/semver"
+)
+
+// solrStatusError is returned when Solr answers with an unexpected status code.
+type solrStatusError struct {
+	url        string
+	statusCode int
+}
+
+func (e *solrStatusError) Error() string {
+	return fmt.Sprintf("solr: API responded with status-code %d, expected %d, url %s",
+		e.statusCode, http.StatusOK, e.url)
+}
+
+// hasStatusCode reports whether err was caused by Solr answering with code.
+func hasStatusCode(err error, code int) bool {
+	statusErr, ok := err.(*solrStatusError)
+	return ok && statusErr.statusCode == code
+}
+
+// getBody queries the given url and returns the response body.
+func getBody(client http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &solrStatusError{url: url, statusCode: resp.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read response body: %v", err)
+	}
+	return body, nil
+}
+
+// getJSON queries the given url and unmarshals the JSON response into v.
+func getJSON(client http.Client, url string, v interface{}) error {
+	body, err := getBody(client, url)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// postJSON sends v as a JSON request body to the given url and unmarshals the
+// JSON response into response.
+func postJSON(client http.Client, url string, v interface{}, response interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &solrStatusError{url: url, statusCode: resp.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to read response body: %v", err)
+	}
+	return json.Unmarshal(body, response)
+}
+
+// getInfoSystem returns the system information of the solr instance answering on solrInfoURL.
+func getInfoSystem(client http.Client, solrInfoURL string) (*InfoSystem, error) {
+	resp, err := client.Get(solrInfoURL)
+	if err != nil {
+		return nil, fmt.Errorf("Error while querying Solr for infos : %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read infos response body: %v", err)
+	}
+
+	infoSystem := &InfoSystem{}
+	err = json.Unmarshal(body, infoSystem)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal solr infos JSON into struct: %v", err)
+	}
+	return infoSystem, nil
+}
+
+// SolrVersion returns the semantic version of solr.
+func (i *InfoSystem) SolrVersion() (semver.Version, error) {
+	semanticVersion, err := semver.Make(i.Lucene.SolrVersion)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("Error parsing version string: %v", err)
+	}
+	return semanticVersion, nil
+}
+
+// getSolrVersion returns the version of the solr instance answering on solrInfoURL.
+func getSolrVersion(client http.Client, solrInfoURL string) (semver.Version, error) {
+	infoSystem, err := getInfoSystem(client, solrInfoURL)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return infoSystem.SolrVersion()
+}
+
+// getAdminCoresStatus returns the status of the cores hosted by the solr instance.
+func getAdminCoresStatus(client http.Client, adminCoresURL string) (*AdminCoresStatus, error) {
+	adminCoresStatus := &AdminCoresStatus{}
+	if err := getJSON(client, adminCoresURL, adminCoresStatus); err != nil {
+		return nil, fmt.Errorf("Error while querying Solr for admin stats: %v", err)
+	}
+	return adminCoresStatus, nil
+}
+
+// isExcludedCore reports whether the core is excluded from monitoring.
+func isExcludedCore(core string) bool {
+	return *solrExcludedCore != "" && regexp.MustCompile(*solrExcludedCore).MatchString(core)
+}
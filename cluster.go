@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var clusterStatusPath = "/admin/collections?action=CLUSTERSTATUS&wt=json"
+
+var (
+	replicaStates = []string{"active", "down", "recovering", "recovery_failed"}
+	shardStates   = []string{"active", "inactive", "construction", "recovery", "recovery_failed"}
+)
+
+// ClusterCollector collects SolrCloud topology metrics from solr
+type ClusterCollector struct {
+	liveNodes      *prometheus.Desc
+	collectionInfo *prometheus.Desc
+	shardState     *prometheus.Desc
+	shardLeader    *prometheus.Desc
+	replicaState   *prometheus.Desc
+	replicaLeader  *prometheus.Desc
+	replicaType    *prometheus.Desc
+
+	tracker *TopologyTracker
+
+	client           http.Client
+	clusterStatusURL string
+}
+
+// NewClusterCollector returns a new Collector exposing solrcloud cluster statistics.
+func NewClusterCollector(client http.Client, solrBaseURL string) (*ClusterCollector, error) {
+	clusterStatusURL := fmt.Sprintf("%s%s", solrBaseURL, clusterStatusPath)
+	replicaLabels := []string{"collection", "shard", "replica", "core", "node"}
+	return &ClusterCollector{
+		liveNodes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "live_nodes"),
+			"Number of live nodes in the cluster.",
+			[]string{},
+			nil,
+		),
+		collectionInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "collection_info"),
+			"Collection configuration, value is always 1.",
+			[]string{"collection", "config_name", "router"},
+			nil,
+		),
+		shardState: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "shard_state"),
+			"Shard state, 1 for the current state and 0 for the others.",
+			[]string{"collection", "shard", "state"},
+			nil,
+		),
+		shardLeader: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "shard_leader_active"),
+			"Whether the shard has an active leader on a live node.",
+			[]string{"collection", "shard"},
+			nil,
+		),
+		replicaState: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "replica_state"),
+			"Replica state, 1 for the current state and 0 for the others.",
+			append(replicaLabels, "state"),
+			nil,
+		),
+		replicaLeader: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "replica_leader"),
+			"Whether the replica is the leader of its shard.",
+			replicaLabels,
+			nil,
+		),
+		replicaType: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "replica_type"),
+			"Replica type (NRT, TLOG or PULL), value is always 1.",
+			append(replicaLabels, "type"),
+			nil,
+		),
+		tracker:          NewTopologyTracker(),
+		client:           client,
+		clusterStatusURL: clusterStatusURL,
+	}, nil
+}
+
+// Update exposes cluster related metrics from solr.
+func (c *ClusterCollector) Update(ch chan<- prometheus.Metric) error {
+	clusterStatus := &ClusterStatus{}
+	if err := getJSON(c.client, c.clusterStatusURL, clusterStatus); err != nil {
+		// Solr answers with a bad request when it is not running in SolrCloud mode
+		if hasStatusCode(err, http.StatusBadRequest) {
+			log.Debugf("Solr is not running in SolrCloud mode, skipping cluster status")
+			return nil
+		}
+		return fmt.Errorf("Error while querying Solr for cluster status: %v", err)
+	}
+	c.tracker.Observe(clusterStatus, time.Now())
+
+	liveNodes := make(map[string]bool, len(clusterStatus.Cluster.LiveNodes))
+	for _, node := range clusterStatus.Cluster.LiveNodes {
+		liveNodes[node] = true
+	}
+	ch <- prometheus.MustNewConstMetric(c.liveNodes, prometheus.GaugeValue, float64(len(liveNodes)))
+
+	for collectionName, collection := range clusterStatus.Cluster.Collections {
+		ch <- prometheus.MustNewConstMetric(c.collectionInfo, prometheus.GaugeValue, 1, collectionName, collection.ConfigName, collection.Router.Name)
+
+		for shardName, shard := range collection.Shards {
+			for _, state := range shardStates {
+				ch <- prometheus.MustNewConstMetric(c.shardState, prometheus.GaugeValue, boolToFloat64(shard.State == state), collectionName, shardName, state)
+			}
+
+			leaderActive := false
+			for replicaName, replica := range shard.Replicas {
+				if replica.IsLeader() && replica.State == "active" && liveNodes[replica.NodeName] {
+					leaderActive = true
+				}
+
+				for _, state := range replicaStates {
+					ch <- prometheus.MustNewConstMetric(c.replicaState, prometheus.GaugeValue, boolToFloat64(replica.State == state), collectionName, shardName, replicaName, replica.Core, replica.NodeName, state)
+				}
+				ch <- prometheus.MustNewConstMetric(c.replicaLeader, prometheus.GaugeValue, boolToFloat64(replica.IsLeader()), collectionName, shardName, replicaName, replica.Core, replica.NodeName)
+				ch <- prometheus.MustNewConstMetric(c.replicaType, prometheus.GaugeValue, 1, collectionName, shardName, replicaName, replica.Core, replica.NodeName, replica.ReplicaType())
+			}
+			ch <- prometheus.MustNewConstMetric(c.shardLeader, prometheus.GaugeValue, boolToFloat64(leaderActive), collectionName, shardName)
+		}
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *ClusterCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect cluster metrics: %v", err)
+	}
+	c.tracker.Collect(ch)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *ClusterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.liveNodes
+	ch <- c.collectionInfo
+	ch <- c.shardState
+	ch <- c.shardLeader
+	ch <- c.replicaState
+	ch <- c.replicaLeader
+	ch <- c.replicaType
+	c.tracker.Describe(ch)
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
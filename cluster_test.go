@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Response of the Solr 7 CLUSTERSTATUS action for a two shards collection.
+const clusterStatusResponse = `{
+  "responseHeader":{
+    "status":0,
+    "QTime":2},
+  "cluster":{
+    "collections":{
+      "products":{
+        "pullReplicas":"0",
+        "replicationFactor":"2",
+        "shards":{
+          "shard1":{
+            "range":"80000000-ffffffff",
+            "state":"active",
+            "replicas":{
+              "core_node3":{
+                "core":"products_shard1_replica_n1",
+                "base_url":"http://10.0.0.1:8983/solr",
+                "node_name":"10.0.0.1:8983_solr",
+                "state":"active",
+                "type":"NRT",
+                "force_set_state":"false",
+                "leader":"true"},
+              "core_node5":{
+                "core":"products_shard1_replica_t2",
+                "base_url":"http://10.0.0.2:8983/solr",
+                "node_name":"10.0.0.2:8983_solr",
+                "state":"recovering",
+                "type":"TLOG",
+                "force_set_state":"false"}}},
+          "shard2":{
+            "range":"0-7fffffff",
+            "state":"active",
+            "replicas":{
+              "core_node7":{
+                "core":"products_shard2_replica_n4",
+                "base_url":"http://10.0.0.3:8983/solr",
+                "node_name":"10.0.0.3:8983_solr",
+                "state":"active",
+                "type":"NRT",
+                "force_set_state":"false",
+                "leader":"true"}}}},
+        "router":{"name":"compositeId"},
+        "maxShardsPerNode":"1",
+        "autoAddReplicas":"false",
+        "nrtReplicas":"2",
+        "tlogReplicas":"0",
+        "znodeVersion":12,
+        "configName":"products_conf"}},
+    "live_nodes":["10.0.0.1:8983_solr", "10.0.0.2:8983_solr"]}}`
+
+func TestClusterCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, clusterStatusResponse)
+	}))
+	defer server.Close()
+
+	collector, err := NewClusterCollector(*http.DefaultClient, server.URL+"/solr")
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	metrics := collectMetrics(collector)
+	leader := "collection=products,core=products_shard1_replica_n1,node=10.0.0.1:8983_solr,replica=core_node3,shard=shard1"
+	follower := "collection=products,core=products_shard1_replica_t2,node=10.0.0.2:8983_solr,replica=core_node5,shard=shard1"
+	for metric, want := range map[string]float64{
+		"solr_cluster_live_nodes{}": 2,
+		"solr_cluster_collection_info{collection=products,config_name=products_conf,router=compositeId}": 1,
+		"solr_cluster_shard_state{collection=products,shard=shard1,state=active}":                        1,
+		"solr_cluster_shard_state{collection=products,shard=shard1,state=inactive}":                      0,
+		"solr_cluster_shard_leader_active{collection=products,shard=shard1}":                             1,
+		// The leader of shard2 is not on a live node
+		"solr_cluster_shard_leader_active{collection=products,shard=shard2}": 0,
+		"solr_cluster_replica_state{" + leader + ",state=active}":            1,
+		"solr_cluster_replica_state{" + leader + ",state=recovering}":        0,
+		"solr_cluster_replica_state{" + follower + ",state=active}":          0,
+		"solr_cluster_replica_state{" + follower + ",state=recovering}":      1,
+		"solr_cluster_replica_leader{" + leader + "}":                        1,
+		"solr_cluster_replica_leader{" + follower + "}":                      0,
+		"solr_cluster_replica_type{" + leader + ",type=NRT}":                 1,
+		"solr_cluster_replica_type{" + follower + ",type=TLOG}":              1,
+	} {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if got := m.GetGauge().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+
+	// Exactly one state is set for each replica
+	states := map[string]float64{}
+	for metric, m := range metrics {
+		if strings.HasPrefix(metric, "solr_cluster_replica_state{") {
+			replica := metric[:strings.Index(metric, ",state=")]
+			states[replica] += m.GetGauge().GetValue()
+		}
+	}
+	if len(states) != 3 {
+		t.Errorf("got the states of %d replicas, want 3", len(states))
+	}
+	for replica, sum := range states {
+		if sum != 1 {
+			t.Errorf("%s has %v states set, want 1", replica, sum)
+		}
+	}
+}
+
+func TestClusterCollectorNotCloud(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": {"msg": "Solr instance is not running in SolrCloud mode.", "code": 400}}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	collector, err := NewClusterCollector(*http.DefaultClient, server.URL+"/solr")
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+	if err := collector.Update(make(chan prometheus.Metric, 100)); err != nil {
+		t.Errorf("Update() = %v, want no error outside of SolrCloud", err)
+	}
+	for metric := range collectMetrics(collector) {
+		if strings.HasPrefix(metric, "solr_cluster_replica_") || strings.HasPrefix(metric, "solr_cluster_live_nodes") {
+			t.Errorf("unexpected metric %s outside of SolrCloud", metric)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config is the content of the exporter configuration file
+type Config struct {
+	Probes    []ProbeConfig     `json:"probes"`
+	Queries   []QueryConfig     `json:"queries"`
+	Freshness []FreshnessConfig `json:"freshness"`
+	Canary    *CanaryConfig     `json:"canary"`
+}
+
+// ProbeConfig describes a query periodically sent to solr
+type ProbeConfig struct {
+	Name        string      `json:"name"`
+	Target      string      `json:"target"`
+	Handler     string      `json:"handler"`
+	Params      QueryParams `json:"params"`
+	Interval    Duration    `json:"interval"`
+	MinNumFound *int64      `json:"min_num_found"`
+	MaxNumFound *int64      `json:"max_num_found"`
+	MaxQTime    Duration    `json:"max_qtime"`
+	DebugTiming bool        `json:"debug_timing"`
+}
+
+// QueryConfig describes a query whose results are exported as metrics
+type QueryConfig struct {
+	Name     string      `json:"name"`
+	Target   string      `json:"target"`
+	Handler  string      `json:"handler"`
+	Params   QueryParams `json:"params"`
+	Interval Duration    `json:"interval"`
+}
+
+// FreshnessConfig describes a date field whose newest value tells how fresh
+// the collection is
+type FreshnessConfig struct {
+	Collection  string      `json:"collection"`
+	Field       string      `json:"field"`
+	MultiValued bool        `json:"multi_valued"`
+	Params      QueryParams `json:"params"`
+}
+
+// CanaryConfig describes the canary documents written to measure how long it
+// takes for an update to become visible
+type CanaryConfig struct {
+	Collection     string   `json:"collection"`
+	IDPrefix       string   `json:"id_prefix"`
+	TimestampField string   `json:"timestamp_field"`
+	RealTimeGet    bool     `json:"realtime_get"`
+	Interval       Duration `json:"interval"`
+	Timeout        Duration `json:"timeout"`
+	PollInterval   Duration `json:"poll_interval"`
+}
+
+// Duration is a time.Duration unmarshaled from strings like "30s".
+type Duration time.Duration
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("Duration must be a string like \"30s\": %v", err)
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// QueryParams are solr request parameters, each parameter accepting either a
+// single value or a list of values.
+type QueryParams map[string]StringList
+
+// Values returns the parameters as url values.
+func (p QueryParams) Values() url.Values {
+	values := url.Values{}
+	for name, list := range p {
+		for _, value := range list {
+			values.Add(name, value)
+		}
+	}
+	return values
+}
+
+// StringList is a list of strings also accepting a single string.
+type StringList []string
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = StringList{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("Parameter must be a string or a list of strings: %v", err)
+	}
+	*l = StringList(list)
+	return nil
+}
+
+// LoadConfig reads and validates the configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config file: %v", err)
+	}
+	return parseConfig(content)
+}
+
+// normalizeHandler defaults to the select handler and makes sure the handler
+// path starts with a slash.
+func normalizeHandler(handler string) string {
+	if handler == "" {
+		return "/select"
+	}
+	if !strings.HasPrefix(handler, "/") {
+		return "/" + handler
+	}
+	return handler
+}
+
+func parseConfig(content []byte) (*Config, error) {
+	config := &Config{}
+	if err := json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("Failed to parse config file: %v", err)
+	}
+
+	probeNames := map[string]bool{}
+	for i := range config.Probes {
+		probe := &config.Probes[i]
+		if probe.Name == "" {
+			return nil, fmt.Errorf("Probe #%d has no name", i+1)
+		}
+		if probeNames[probe.Name] {
+			return nil, fmt.Errorf("Probe %s is defined twice", probe.Name)
+		}
+		probeNames[probe.Name] = true
+		if probe.Target == "" {
+			return nil, fmt.Errorf("Probe %s has no target", probe.Name)
+		}
+		probe.Handler = normalizeHandler(probe.Handler)
+	}
+
+	queryNames := map[string]bool{}
+	for i := range config.Queries {
+		query := &config.Queries[i]
+		if query.Name == "" {
+			return nil, fmt.Errorf("Query #%d has no name", i+1)
+		}
+		if queryNames[query.Name] {
+			return nil, fmt.Errorf("Query %s is defined twice", query.Name)
+		}
+		queryNames[query.Name] = true
+		if query.Target == "" {
+			return nil, fmt.Errorf("Query %s has no target", query.Name)
+		}
+		query.Handler = normalizeHandler(query.Handler)
+	}
+
+	for i, freshness := range config.Freshness {
+		if freshness.Collection == "" || freshness.Field == "" {
+			return nil, fmt.Errorf("Freshness #%d needs both a collection and a field", i+1)
+		}
+	}
+
+	if canary := config.Canary; canary != nil {
+		if canary.Collection == "" {
+			return nil, fmt.Errorf("Canary has no collection")
+		}
+		if canary.IDPrefix == "" {
+			canary.IDPrefix = "solr-exporter-canary-"
+		}
+		if canary.TimestampField == "" {
+			canary.TimestampField = "canary_timestamp_dt"
+		}
+		if canary.Interval == 0 {
+			canary.Interval = Duration(time.Minute)
+		}
+		if canary.Timeout == 0 {
+			canary.Timeout = Duration(30 * time.Second)
+		}
+		if canary.PollInterval == 0 {
+			canary.PollInterval = Duration(100 * time.Millisecond)
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dataImportStatusPath = "?command=status&wt=json"
+	// Layout of the dates in the DataImportHandler status messages, in the
+	// timezone of the solr JVM.
+	dataImportDateLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	gaugeDataImportMetrics = map[string]string{
+		"busy":                                "busy",
+		"rows_fetched":                        "rows_fetched",
+		"documents_processed":                 "documents_processed",
+		"documents_skipped":                   "documents_skipped",
+		"documents_deleted":                   "documents_deleted",
+		"last_import_start_timestamp_seconds": "last_import_start_timestamp_seconds",
+		"last_import_duration_seconds":        "last_import_duration_seconds",
+		"failed":                              "failed",
+	}
+	dataImportDeletedRegexp = regexp.MustCompile(`Deleted (\d+) documents`)
+)
+
+// isDataImportHandler reports whether the query handler class is a DataImportHandler.
+func isDataImportHandler(class string) bool {
+	return strings.HasSuffix(class, ".DataImportHandler")
+}
+
+// parseDataImportDuration parses the "Time taken" status message, formatted as
+// hours:minutes:seconds.
+func parseDataImportDuration(timeTaken string) (float64, error) {
+	parts := strings.Split(timeTaken, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("Unexpected duration %q", timeTaken)
+	}
+	duration := 0.0
+	for i, unit := range []float64{3600, 60, 1} {
+		value, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("Unexpected duration %q: %v", timeTaken, err)
+		}
+		duration += value * unit
+	}
+	return duration, nil
+}
+
+func processDataImport(e *Exporter, coreName string, handler string, data io.Reader) []error {
+	dataImportStatus := &DataImportStatus{}
+	errors := []error{}
+	if err := json.NewDecoder(data).Decode(dataImportStatus); err != nil {
+		errors = append(errors, fmt.Errorf("Failed to unmarshal dataimport status JSON into struct (core : %s, handler : %s): %v", coreName, handler, err))
+		return errors
+	}
+
+	messages := dataImportStatus.StatusMessages
+	// The summary of the last import is stored under an empty key.
+	summary := messages[""]
+	failed := strings.HasPrefix(summary, "Indexing failed") || messages["Full Import failed"] != "" || messages["Delta Import Failed"] != "" || messages["Aborted"] != ""
+
+	e.gaugeDataImport["busy"].WithLabelValues(coreName, handler).Set(boolToFloat64(dataImportStatus.Status == "busy"))
+	e.gaugeDataImport["failed"].WithLabelValues(coreName, handler).Set(boolToFloat64(failed))
+
+	for name, message := range map[string]string{
+		"rows_fetched":        "Total Rows Fetched",
+		"documents_processed": "Total Documents Processed",
+		"documents_skipped":   "Total Documents Skipped",
+	} {
+		if value, err := strconv.ParseFloat(messages[message], 64); err == nil {
+			e.gaugeDataImport[name].WithLabelValues(coreName, handler).Set(value)
+		}
+	}
+
+	if deleted, err := strconv.ParseFloat(messages["Total Documents Deleted"], 64); err == nil {
+		e.gaugeDataImport["documents_deleted"].WithLabelValues(coreName, handler).Set(deleted)
+	} else if match := dataImportDeletedRegexp.FindStringSubmatch(summary); match != nil {
+		deleted, _ := strconv.ParseFloat(match[1], 64)
+		e.gaugeDataImport["documents_deleted"].WithLabelValues(coreName, handler).Set(deleted)
+	}
+
+	for _, message := range []string{"Full Dump Started", "Delta Dump started"} {
+		if started, err := time.ParseInLocation(dataImportDateLayout, messages[message], e.location); err == nil {
+			e.gaugeDataImport["last_import_start_timestamp_seconds"].WithLabelValues(coreName, handler).Set(float64(started.Unix()))
+		}
+	}
+
+	if timeTaken, ok := messages["Time taken"]; ok {
+		duration, err := parseDataImportDuration(strings.TrimSpace(timeTaken))
+		if err != nil {
+			errors = append(errors, err)
+		} else {
+			e.gaugeDataImport["last_import_duration_seconds"].WithLabelValues(coreName, handler).Set(duration)
+		}
+	}
+
+	return errors
+}
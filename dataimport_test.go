@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessDataImport(t *testing.T) {
+	exporter := NewExporter("", time.Second, "", http.Client{}, time.Local)
+
+	errors := processDataImport(exporter, "products", "/dataimport", strings.NewReader(`{
+		"responseHeader": {"status": 0, "QTime": 0},
+		"command": "status",
+		"status": "idle",
+		"importResponse": "",
+		"statusMessages": {
+			"Total Requests made to DataSource": "1",
+			"Total Rows Fetched": "120",
+			"Total Documents Processed": "118",
+			"Total Documents Skipped": "2",
+			"Full Dump Started": "2018-10-01 12:00:00",
+			"": "Indexing completed. Added/Updated: 118 documents. Deleted 3 documents.",
+			"Committed": "2018-10-01 12:01:05",
+			"Time taken": "0:1:5.250"
+		}
+	}`))
+	if len(errors) != 0 {
+		t.Fatalf("processDataImport() returned errors: %v", errors)
+	}
+
+	started, _ := time.ParseInLocation(dataImportDateLayout, "2018-10-01 12:00:00", time.Local)
+	for name, want := range map[string]float64{
+		"busy":                                0,
+		"failed":                              0,
+		"rows_fetched":                        120,
+		"documents_processed":                 118,
+		"documents_skipped":                   2,
+		"documents_deleted":                   3,
+		"last_import_start_timestamp_seconds": float64(started.Unix()),
+		"last_import_duration_seconds":        65.25,
+	} {
+		if got := gaugeValue(t, exporter.gaugeDataImport[name].WithLabelValues("products", "/dataimport")); got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	errors = processDataImport(exporter, "orders", "/dataimport", strings.NewReader(`{
+		"status": "busy",
+		"statusMessages": {
+			"Time Elapsed": "0:0:3.1",
+			"Total Rows Fetched": "10",
+			"Delta Dump started": "2018-10-01 13:00:00",
+			"": "Indexing failed. Rolled back all changes.",
+			"Rolledback": "2018-10-01 13:00:03"
+		}
+	}`))
+	if len(errors) != 0 {
+		t.Fatalf("processDataImport() returned errors: %v", errors)
+	}
+	if busy := gaugeValue(t, exporter.gaugeDataImport["busy"].WithLabelValues("orders", "/dataimport")); busy != 1 {
+		t.Errorf("busy = %v, want 1", busy)
+	}
+	if failed := gaugeValue(t, exporter.gaugeDataImport["failed"].WithLabelValues("orders", "/dataimport")); failed != 1 {
+		t.Errorf("failed = %v, want 1", failed)
+	}
+}
+
+func TestProcessDataImportTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+	exporter := NewExporter("", time.Second, "", http.Client{}, tokyo)
+
+	errors := processDataImport(exporter, "products", "/dataimport", strings.NewReader(`{
+		"status": "idle",
+		"statusMessages": {"Full Dump Started": "2018-10-01 12:00:00"}
+	}`))
+	if len(errors) != 0 {
+		t.Fatalf("processDataImport() returned errors: %v", errors)
+	}
+
+	// 12:00 in Tokyo is 03:00 UTC
+	want := float64(time.Date(2018, 10, 1, 3, 0, 0, 0, time.UTC).Unix())
+	if got := gaugeValue(t, exporter.gaugeDataImport["last_import_start_timestamp_seconds"].WithLabelValues("products", "/dataimport")); got != want {
+		t.Errorf("last_import_start_timestamp_seconds = %v, want %v", got, want)
+	}
+}
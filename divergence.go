@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// DivergenceCollector compares the index of every replica with the index of its
+// shard leader
+type DivergenceCollector struct {
+	numDocsDiff      *prometheus.Desc
+	indexVersionDiff *prometheus.Desc
+	generationDiff   *prometheus.Desc
+
+	client           http.Client
+	clusterStatusURL string
+}
+
+// NewDivergenceCollector returns a new Collector exposing replica divergence statistics.
+func NewDivergenceCollector(client http.Client, solrBaseURL string) (*DivergenceCollector, error) {
+	clusterStatusURL := fmt.Sprintf("%s%s", solrBaseURL, clusterStatusPath)
+	replicaLabels := []string{"collection", "shard", "replica", "core"}
+	return &DivergenceCollector{
+		numDocsDiff: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "replica_num_docs_diff"),
+			"Difference between the number of documents of the replica and its shard leader.",
+			replicaLabels,
+			nil,
+		),
+		indexVersionDiff: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "replica_index_version_diff"),
+			"Difference between the index version of the replica and its shard leader.",
+			replicaLabels,
+			nil,
+		),
+		generationDiff: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "replica_generation_diff"),
+			"Difference between the index generation of the replica and its shard leader.",
+			replicaLabels,
+			nil,
+		),
+		client:           client,
+		clusterStatusURL: clusterStatusURL,
+	}, nil
+}
+
+// segmentsGeneration returns the generation encoded in a segments file name,
+// like segments_a for generation 10.
+func segmentsGeneration(segmentsFile string) int64 {
+	i := strings.LastIndex(segmentsFile, "_")
+	if i < 0 {
+		return 0
+	}
+	generation, err := strconv.ParseInt(segmentsFile[i+1:], 36, 64)
+	if err != nil {
+		return 0
+	}
+	return generation
+}
+
+// getNodesCoresStatus fetches the cores status of every node hosting a replica,
+// indexed by base url and core name.
+func (c *DivergenceCollector) getNodesCoresStatus(clusterStatus *ClusterStatus) map[string]*AdminCoresStatus {
+	nodes := map[string]*AdminCoresStatus{}
+	for _, collection := range clusterStatus.Cluster.Collections {
+		for _, shard := range collection.Shards {
+			for _, replica := range shard.Replicas {
+				if _, ok := nodes[replica.BaseURL]; ok {
+					continue
+				}
+				adminCoresStatus, err := getAdminCoresStatus(c.client, fmt.Sprintf("%s%s", replica.BaseURL, adminCoresPath))
+				if err != nil {
+					log.Errorf("Failed to get cores status of node %s: %v", replica.BaseURL, err)
+				}
+				nodes[replica.BaseURL] = adminCoresStatus
+			}
+		}
+	}
+	return nodes
+}
+
+// Update exposes replica divergence metrics from solr.
+func (c *DivergenceCollector) Update(ch chan<- prometheus.Metric) error {
+	clusterStatus := &ClusterStatus{}
+	if err := getJSON(c.client, c.clusterStatusURL, clusterStatus); err != nil {
+		// Solr answers with a bad request when it is not running in SolrCloud mode
+		if hasStatusCode(err, http.StatusBadRequest) {
+			log.Debugf("Solr is not running in SolrCloud mode, skipping replica divergence")
+			return nil
+		}
+		return fmt.Errorf("Error while querying Solr for cluster status: %v", err)
+	}
+
+	nodes := c.getNodesCoresStatus(clusterStatus)
+
+	for collectionName, collection := range clusterStatus.Cluster.Collections {
+		for shardName, shard := range collection.Shards {
+			var leader *ClusterReplica
+			for _, replica := range shard.Replicas {
+				if replica.IsLeader() {
+					leader = &replica
+					break
+				}
+			}
+			if leader == nil || nodes[leader.BaseURL] == nil {
+				continue
+			}
+			leaderStatus, ok := nodes[leader.BaseURL].Status[leader.Core]
+			if !ok {
+				continue
+			}
+
+			for replicaName, replica := range shard.Replicas {
+				if isExcludedCore(replica.Core) || nodes[replica.BaseURL] == nil {
+					continue
+				}
+				replicaStatus, ok := nodes[replica.BaseURL].Status[replica.Core]
+				if !ok {
+					continue
+				}
+
+				ch <- prometheus.MustNewConstMetric(c.numDocsDiff, prometheus.GaugeValue, float64(replicaStatus.Index.NumDocs-leaderStatus.Index.NumDocs), collectionName, shardName, replicaName, replica.Core)
+				ch <- prometheus.MustNewConstMetric(c.indexVersionDiff, prometheus.GaugeValue, float64(replicaStatus.Index.Version-leaderStatus.Index.Version), collectionName, shardName, replicaName, replica.Core)
+				ch <- prometheus.MustNewConstMetric(c.generationDiff, prometheus.GaugeValue, float64(segmentsGeneration(replicaStatus.Index.SegmentsFile)-segmentsGeneration(leaderStatus.Index.SegmentsFile)), collectionName, shardName, replicaName, replica.Core)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *DivergenceCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect replica divergence metrics: %v", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *DivergenceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.numDocsDiff
+	ch <- c.indexVersionDiff
+	ch <- c.generationDiff
+}
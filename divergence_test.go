@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSegmentsGeneration(t *testing.T) {
+	tests := map[string]int64{
+		"segments_1":  1,
+		"segments_a":  10,
+		"segments_2s": 100,
+		"segments":    0,
+		"":            0,
+		"segments_?":  0,
+	}
+
+	for segmentsFile, want := range tests {
+		if got := segmentsGeneration(segmentsFile); got != want {
+			t.Errorf("segmentsGeneration(%q) = %d, want %d", segmentsFile, got, want)
+		}
+	}
+}
+
+func TestDivergenceCollector(t *testing.T) {
+	var leaderURL, replicaURL string
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/solr/admin/collections":
+			fmt.Fprintf(w, `{"cluster": {"collections": {"products": {"shards": {"shard1": {"state": "active", "replicas": {
+				"core_node1": {"core": "products_shard1_replica_n1", "base_url": %q, "node_name": "leader:8983_solr", "state": "active", "leader": "true"},
+				"core_node2": {"core": "products_shard1_replica_n2", "base_url": %q, "node_name": "replica:8983_solr", "state": "active"}
+			}}}}}, "live_nodes": ["leader:8983_solr", "replica:8983_solr"]}}`, leaderURL, replicaURL)
+		case "/solr/admin/cores":
+			fmt.Fprintf(w, `{"status": {"products_shard1_replica_n1": {"index": {"numDocs": 1000, "version": 120, "segmentsFile": "segments_c"}}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer leader.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status": {"products_shard1_replica_n2": {"index": {"numDocs": 990, "version": 115, "segmentsFile": "segments_a"}}}}`)
+	}))
+	defer replica.Close()
+	leaderURL, replicaURL = leader.URL+"/solr", replica.URL+"/solr"
+
+	collector, err := NewDivergenceCollector(*http.DefaultClient, leaderURL)
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	metrics := collectMetrics(collector)
+	for metric, want := range map[string]float64{
+		"solr_cluster_replica_num_docs_diff{collection=products,core=products_shard1_replica_n2,replica=core_node2,shard=shard1}":      -10,
+		"solr_cluster_replica_index_version_diff{collection=products,core=products_shard1_replica_n2,replica=core_node2,shard=shard1}": -5,
+		"solr_cluster_replica_generation_diff{collection=products,core=products_shard1_replica_n2,replica=core_node2,shard=shard1}":    -2,
+		"solr_cluster_replica_num_docs_diff{collection=products,core=products_shard1_replica_n1,replica=core_node1,shard=shard1}":      0,
+		"solr_cluster_replica_generation_diff{collection=products,core=products_shard1_replica_n1,replica=core_node1,shard=shard1}":    0,
+	} {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if got := m.GetGauge().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	if len(metrics) != 6 {
+		t.Errorf("got %d metrics, want 6: %v", len(metrics), metrics)
+	}
+
+	excluded := *solrExcludedCore
+	*solrExcludedCore = "_replica_n2$"
+	defer func() { *solrExcludedCore = excluded }()
+	if metrics := collectMetrics(collector); len(metrics) != 3 {
+		t.Errorf("got %d metrics with the excluded replica, want 3: %v", len(metrics), metrics)
+	}
+}
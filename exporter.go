@@ -1,11 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strconv"
 	"sync"
 	"time"
 
@@ -20,10 +22,20 @@ const (
 
 var (
 	gaugeAdminMetrics = map[string]string{
-		"num_docs":      "num_docs",
-		"size_in_bytes": "size_in_bytes",
-		"deleted_docs":  "deleted_docs",
-		"max_docs":      "max_docs",
+		"num_docs":                        "num_docs",
+		"size_in_bytes":                   "size_in_bytes",
+		"deleted_docs":                    "deleted_docs",
+		"max_docs":                        "max_docs",
+		"segment_count":                   "segment_count",
+		"index_version":                   "index_version",
+		"last_modified_timestamp_seconds": "last_modified_timestamp_seconds",
+		"current":                         "current",
+		"has_deletions":                   "has_deletions",
+		"last_commit_timestamp_seconds":   "last_commit_timestamp_seconds",
+		"seconds_since_last_commit":       "seconds_since_last_commit",
+		"index_heap_usage_bytes":          "index_heap_usage_bytes",
+		"start_time_seconds":              "start_time_seconds",
+		"uptime_seconds":                  "uptime_seconds",
 	}
 	gaugeCoreMetrics = map[string]string{
 		"num_docs":     "num_docs",
@@ -41,6 +53,7 @@ var (
 		"avg_time_per_request":       "avg_time_per_request",
 		"errors":                     "errors",
 		"handler_start":              "handler_start",
+		"handler_start_time_seconds": "handler_start_time_seconds",
 		"median_request_time":        "median_request_time",
 		"requests":                   "requests",
 		"timeouts":                   "timeouts",
@@ -81,6 +94,19 @@ var (
 	}
 )
 
+// Matches the fully qualified exception classes quoted in the init failure messages.
+var exceptionClassRegexp = regexp.MustCompile(`(?:[a-z_$][\w$]*\.)+([A-Z][\w$]*(?:Exception|Error))`)
+
+// initFailureReason turns a core init failure message into a bounded label
+// value: the short class name of the innermost exception quoted in the message.
+func initFailureReason(message string) string {
+	matches := exceptionClassRegexp.FindAllStringSubmatch(message, -1)
+	if len(matches) == 0 {
+		return "unknown"
+	}
+	return matches[len(matches)-1][1]
+}
+
 // Return list of cores from solr server
 func getCoresFromStatus(adminCoresStatus *AdminCoresStatus) []string {
 	serverCores := []string{}
@@ -93,11 +119,18 @@ func getCoresFromStatus(adminCoresStatus *AdminCoresStatus) []string {
 // Exporter collects Solr stats from the given server and exports
 // them using the prometheus metrics package.
 type Exporter struct {
-	mBeansURL    string
-	AdminCoreURL string
-	mutex        sync.RWMutex
-
-	up prometheus.Gauge
+	mBeansURL     string
+	AdminCoreURL  string
+	dataImportURL string
+	location      *time.Location
+	mutex         sync.RWMutex
+
+	up               prometheus.Gauge
+	coreInfo         *prometheus.GaugeVec
+	coreInitFailure  *prometheus.GaugeVec
+	coreInitFailures prometheus.Gauge
+	coreReloads      *prometheus.CounterVec
+	coreStartTimes   map[string]time.Time
 
 	gaugeAdmin  map[string]*prometheus.GaugeVec
 	gaugeCore   map[string]*prometheus.GaugeVec
@@ -105,16 +138,20 @@ type Exporter struct {
 	gaugeUpdate map[string]*prometheus.GaugeVec
 	gaugeCache  map[string]*prometheus.GaugeVec
 
+	gaugeDataImport    map[string]*prometheus.GaugeVec
+	dataImportHandlers map[string][]string
+
 	client http.Client
 }
 
 // NewExporter returns an initialized Exporter.
-func NewExporter(solrBaseURL string, timeout time.Duration, solrExcludedCore string, client http.Client) *Exporter {
+func NewExporter(solrBaseURL string, timeout time.Duration, solrExcludedCore string, client http.Client, location *time.Location) *Exporter {
 	gaugeAdmin := make(map[string]*prometheus.GaugeVec, len(gaugeAdminMetrics))
 	gaugeCore := make(map[string]*prometheus.GaugeVec, len(gaugeCoreMetrics))
 	gaugeQuery := make(map[string]*prometheus.GaugeVec, len(gaugeQueryMetrics))
 	gaugeUpdate := make(map[string]*prometheus.GaugeVec, len(gaugeUpdateMetrics))
 	gaugeCache := make(map[string]*prometheus.GaugeVec, len(gaugeCacheMetrics))
+	gaugeDataImport := make(map[string]*prometheus.GaugeVec, len(gaugeDataImportMetrics))
 
 	for name, help := range gaugeAdminMetrics {
 		gaugeAdmin[name] = prometheus.NewGaugeVec(prometheus.GaugeOpts{
@@ -154,20 +191,48 @@ func NewExporter(solrBaseURL string, timeout time.Duration, solrExcludedCore str
 			Help:      help,
 		}, []string{"core", "handler", "class"})
 	}
+	for name, help := range gaugeDataImportMetrics {
+		gaugeDataImport[name] = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace + "_dataimport",
+			Name:      name,
+			Help:      help,
+		}, []string{"core", "handler"})
+	}
 
 	mBeansURL := fmt.Sprintf("%s%s%s", solrBaseURL, "%s", mbeansPath)
 	AdminCoreURL := fmt.Sprintf("%s%s", solrBaseURL, adminCoresPath)
+	dataImportURL := fmt.Sprintf("%s/%s%s%s", solrBaseURL, "%s", "%s", dataImportStatusPath)
 
 	// Init our exporter.
 	return &Exporter{
-		mBeansURL:    mBeansURL,
-		AdminCoreURL: AdminCoreURL,
+		mBeansURL:     mBeansURL,
+		AdminCoreURL:  AdminCoreURL,
+		dataImportURL: dataImportURL,
+		location:      location,
 
 		up: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "up",
 			Help:      "Was the Solr instance query successful?",
 		}),
+		coreInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "core",
+			Name:      "info",
+			Help:      "Directories and configuration files of the core.",
+		}, []string{"core", "instance_dir", "data_dir", "config", "schema"}),
+		coreInitFailure: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "core",
+			Name:      "init_failure",
+			Help:      "Core which failed to initialize, by exception class.",
+		}, []string{"core", "reason"}),
+		coreInitFailures: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "core",
+			Name:      "init_failures",
+			Help:      "Number of cores which failed to initialize.",
+		}),
 
 		gaugeAdmin:  gaugeAdmin,
 		gaugeCore:   gaugeCore,
@@ -175,6 +240,17 @@ func NewExporter(solrBaseURL string, timeout time.Duration, solrExcludedCore str
 		gaugeUpdate: gaugeUpdate,
 		gaugeCache:  gaugeCache,
 
+		coreReloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "core",
+			Name:      "reloads_total",
+			Help:      "Number of core reloads observed by the exporter, including the ones caused by restarts.",
+		}, []string{"core"}),
+		coreStartTimes: map[string]time.Time{},
+
+		gaugeDataImport:    gaugeDataImport,
+		dataImportHandlers: map[string][]string{},
+
 		client: client,
 	}
 }
@@ -183,6 +259,10 @@ func NewExporter(solrBaseURL string, timeout time.Duration, solrExcludedCore str
 // exporter. It implements prometheus.Collector.
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.up.Desc()
+	e.coreInfo.Describe(ch)
+	e.coreInitFailure.Describe(ch)
+	ch <- e.coreInitFailures.Desc()
+	e.coreReloads.Describe(ch)
 
 	for _, vec := range e.gaugeAdmin {
 		vec.Describe(ch)
@@ -199,6 +279,9 @@ func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	for _, vec := range e.gaugeCache {
 		vec.Describe(ch)
 	}
+	for _, vec := range e.gaugeDataImport {
+		vec.Describe(ch)
+	}
 }
 
 // Collect fetches the stats from configured solr location and
@@ -208,6 +291,8 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	defer e.mutex.Unlock()
 
 	// Reset metrics.
+	e.coreInfo.Reset()
+	e.coreInitFailure.Reset()
 	for _, vec := range e.gaugeAdmin {
 		vec.Reset()
 	}
@@ -223,6 +308,10 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	for _, vec := range e.gaugeCache {
 		vec.Reset()
 	}
+	for _, vec := range e.gaugeDataImport {
+		vec.Reset()
+	}
+	e.dataImportHandlers = map[string][]string{}
 
 	e.up.Set(0)
 	defer func() { ch <- e.up }()
@@ -250,6 +339,7 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 		return
 	}
 
+	startTimes := map[string]time.Time{}
 	for core, metrics := range adminCoresStatus.Status {
 		if solrExcludedCoreString != "" && regexExludedCore.MatchString(core) {
 			continue
@@ -258,7 +348,49 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 		e.gaugeAdmin["size_in_bytes"].WithLabelValues(core).Set(float64(metrics.Index.SizeInBytes))
 		e.gaugeAdmin["deleted_docs"].WithLabelValues(core).Set(float64(metrics.Index.DeletedDocs))
 		e.gaugeAdmin["max_docs"].WithLabelValues(core).Set(float64(metrics.Index.MaxDoc))
+		e.gaugeAdmin["segment_count"].WithLabelValues(core).Set(float64(metrics.Index.SegmentCount))
+		e.gaugeAdmin["index_version"].WithLabelValues(core).Set(float64(metrics.Index.Version))
+		e.gaugeAdmin["current"].WithLabelValues(core).Set(boolToFloat64(metrics.Index.Current))
+		e.gaugeAdmin["has_deletions"].WithLabelValues(core).Set(boolToFloat64(metrics.Index.HasDeletions))
+		e.gaugeAdmin["uptime_seconds"].WithLabelValues(core).Set(float64(metrics.Uptime) / 1000)
+		// Solr reports -1 when the heap usage cannot be computed
+		if metrics.Index.IndexHeapUsageBytes >= 0 {
+			e.gaugeAdmin["index_heap_usage_bytes"].WithLabelValues(core).Set(float64(metrics.Index.IndexHeapUsageBytes))
+		}
+		if lastModified, err := time.Parse(time.RFC3339, metrics.Index.LastModified); err == nil {
+			e.gaugeAdmin["last_modified_timestamp_seconds"].WithLabelValues(core).Set(float64(lastModified.UnixNano()) / 1e9)
+		}
+		if commitTime, err := strconv.ParseInt(string(metrics.Index.UserData.CommitTimeMSec), 10, 64); err == nil {
+			e.gaugeAdmin["last_commit_timestamp_seconds"].WithLabelValues(core).Set(float64(commitTime) / 1000)
+			e.gaugeAdmin["seconds_since_last_commit"].WithLabelValues(core).Set(time.Since(time.Unix(0, commitTime*int64(time.Millisecond))).Seconds())
+		}
+		if startTime, err := time.Parse(time.RFC3339, metrics.StartTime); err == nil {
+			e.gaugeAdmin["start_time_seconds"].WithLabelValues(core).Set(float64(startTime.UnixNano()) / 1e9)
+			reloads := e.coreReloads.WithLabelValues(core)
+			if lastStartTime, ok := e.coreStartTimes[core]; ok && startTime.After(lastStartTime) {
+				reloads.Inc()
+			}
+			startTimes[core] = startTime
+		}
+		e.coreInfo.WithLabelValues(core, metrics.InstanceDir, metrics.DataDir, metrics.Config, metrics.Schema).Set(1)
+	}
+	// Forget the cores which were unloaded or deleted.
+	for core := range e.coreStartTimes {
+		if _, ok := startTimes[core]; !ok {
+			e.coreReloads.DeleteLabelValues(core)
+		}
 	}
+	e.coreStartTimes = startTimes
+
+	initFailures := 0
+	for core, message := range adminCoresStatus.InitFailures {
+		if solrExcludedCoreString != "" && regexExludedCore.MatchString(core) {
+			continue
+		}
+		initFailures++
+		e.coreInitFailure.WithLabelValues(core, initFailureReason(message)).Set(1)
+	}
+	e.coreInitFailures.Set(float64(initFailures))
 
 	cores := getCoresFromStatus(adminCoresStatus)
 
@@ -284,9 +416,27 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 		for _, err := range errors {
 			log.Error(err)
 		}
+
+		for _, handler := range e.dataImportHandlers[coreName] {
+			dataImportURL := fmt.Sprintf(e.dataImportURL, coreName, handler)
+			body, err := getBody(e.client, dataImportURL)
+			if err != nil {
+				log.Errorf("Error while querying Solr for dataimport status: %v", err)
+				continue
+			}
+
+			errors := processDataImport(e, coreName, handler, bytes.NewReader(body))
+			for _, err := range errors {
+				log.Error(err)
+			}
+		}
 	}
 
 	// Report metrics.
+	e.coreInfo.Collect(ch)
+	e.coreInitFailure.Collect(ch)
+	ch <- e.coreInitFailures
+	e.coreReloads.Collect(ch)
 	for _, vec := range e.gaugeAdmin {
 		vec.Collect(ch)
 	}
@@ -302,6 +452,9 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	for _, vec := range e.gaugeCache {
 		vec.Collect(ch)
 	}
+	for _, vec := range e.gaugeDataImport {
+		vec.Collect(ch)
+	}
 
 	// Successfully processed stats.
 	e.up.Set(1)
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestInitFailureReason(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{
+			message: "org.apache.solr.common.SolrException:org.apache.solr.common.SolrException: Could not load conf for core products: Can't load schema managed-schema: org.xml.sax.SAXParseException; lineNumber: 12; Element type \"field\" must be followed by attribute specifications",
+			want:    "SAXParseException",
+		},
+		{
+			message: "org.apache.solr.common.SolrException:java.lang.OutOfMemoryError: Java heap space",
+			want:    "OutOfMemoryError",
+		},
+		{
+			message: "org.apache.lucene.store.LockObtainFailedException: Lock held by this virtual machine: /var/solr/data/products/data/index/write.lock",
+			want:    "LockObtainFailedException",
+		},
+		{
+			message: "Error opening new searcher",
+			want:    "unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := initFailureReason(tt.message); got != tt.want {
+			t.Errorf("initFailureReason(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+// startFakeSolrCores serves the given cores STATUS response and the captured
+// mbeans of the gettingstarted core for every core.
+func startFakeSolrCores(t *testing.T, coresStatus func() string) *httptest.Server {
+	mbeans, err := ioutil.ReadFile(path.Join(solrResponseDir, "7.3.0", "mbeans.json"))
+	if err != nil {
+		t.Fatalf("reading mbeans: %v", err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/solr/admin/cores":
+			fmt.Fprint(w, coresStatus())
+		case strings.HasSuffix(r.URL.Path, "/admin/mbeans"):
+			w.Write(mbeans)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestExporterCoresStatus(t *testing.T) {
+	captured, err := ioutil.ReadFile(path.Join(solrResponseDir, "7.3.0", "admin-cores.json"))
+	if err != nil {
+		t.Fatalf("reading cores status: %v", err)
+	}
+	server := startFakeSolrCores(t, func() string { return string(captured) })
+	defer server.Close()
+
+	exporter := NewExporter(server.URL+"/solr", time.Second, "", http.Client{}, time.Local)
+	metrics := collectMetrics(exporter)
+
+	startTime, _ := time.Parse(time.RFC3339, "2018-04-17T08:23:06.724Z")
+	for metric, want := range map[string]float64{
+		"solr_up{}": 1,
+		"solr_admin_segment_count{core=gettingstarted}":      0,
+		"solr_admin_index_version{core=gettingstarted}":      2,
+		"solr_admin_current{core=gettingstarted}":            1,
+		"solr_admin_has_deletions{core=gettingstarted}":      0,
+		"solr_admin_uptime_seconds{core=gettingstarted}":     7.371,
+		"solr_admin_start_time_seconds{core=gettingstarted}": float64(startTime.UnixNano()) / 1e9,
+		"solr_core_info{config=solrconfig.xml,core=gettingstarted,data_dir=/opt/solr/server/solr/gettingstarted/data/,instance_dir=/opt/solr/server/solr/gettingstarted,schema=managed-schema}": 1,
+		"solr_core_init_failures{}": 0,
+	} {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if got := m.GetGauge().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	// Nothing was committed to the core yet
+	for _, metric := range []string{
+		"solr_admin_last_commit_timestamp_seconds{core=gettingstarted}",
+		"solr_admin_seconds_since_last_commit{core=gettingstarted}",
+		"solr_admin_last_modified_timestamp_seconds{core=gettingstarted}",
+	} {
+		if _, ok := metrics[metric]; ok {
+			t.Errorf("unexpected metric %s without commit", metric)
+		}
+	}
+}
+
+func TestExporterCoresStatusCommitted(t *testing.T) {
+	commitTime := time.Now().Add(-time.Minute)
+	server := startFakeSolrCores(t, func() string {
+		return fmt.Sprintf(`{
+  "responseHeader":{"status":0, "QTime":1},
+  "initFailures":{
+    "broken":"org.apache.solr.common.SolrException:org.apache.solr.common.SolrException: Could not load conf for core broken: Error loading solr config from solrconfig.xml"},
+  "status":{
+    "products":{
+      "name":"products",
+      "instanceDir":"/var/solr/data/products",
+      "dataDir":"/var/solr/data/products/data/",
+      "config":"solrconfig.xml",
+      "schema":"managed-schema",
+      "startTime":"2018-10-01T12:00:00.000Z",
+      "uptime":3600000,
+      "index":{
+        "numDocs":1000,
+        "maxDoc":1100,
+        "deletedDocs":100,
+        "indexHeapUsageBytes":-1,
+        "version":42,
+        "segmentCount":7,
+        "current":true,
+        "hasDeletions":true,
+        "directory":"org.apache.lucene.store.NRTCachingDirectory:NRTCachingDirectory(MMapDirectory@/var/solr/data/products/data/index lockFactory=org.apache.lucene.store.NativeFSLockFactory@1bb72d1; maxCacheMB=48.0 maxMergeSizeMB=4.0)",
+        "segmentsFile":"segments_b",
+        "segmentsFileSizeInBytes":512,
+        "userData":{"commitCommandVer":"1613497891735715840", "commitTimeMSec":"%d"},
+        "lastModified":"2018-10-01T12:30:00.000Z",
+        "sizeInBytes":123456,
+        "size":"120.56 KB"}}}}`, commitTime.UnixNano()/int64(time.Millisecond))
+	})
+	defer server.Close()
+
+	exporter := NewExporter(server.URL+"/solr", time.Second, "", http.Client{}, time.Local)
+	metrics := collectMetrics(exporter)
+
+	for metric, want := range map[string]float64{
+		"solr_admin_segment_count{core=products}":                   7,
+		"solr_admin_index_version{core=products}":                   42,
+		"solr_admin_has_deletions{core=products}":                   1,
+		"solr_admin_uptime_seconds{core=products}":                  3600,
+		"solr_admin_start_time_seconds{core=products}":              float64(time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC).Unix()),
+		"solr_admin_last_modified_timestamp_seconds{core=products}": float64(time.Date(2018, 10, 1, 12, 30, 0, 0, time.UTC).Unix()),
+		"solr_admin_last_commit_timestamp_seconds{core=products}":   float64(commitTime.UnixNano()/int64(time.Millisecond)) / 1000,
+		"solr_core_init_failures{}":                                 1,
+		"solr_core_init_failure{core=broken,reason=SolrException}":  1,
+	} {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if got := m.GetGauge().GetValue(); math.Abs(got-want) > 1e-3 {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	if got := metrics["solr_admin_seconds_since_last_commit{core=products}"].GetGauge().GetValue(); math.Abs(got-60) > 5 {
+		t.Errorf("seconds since last commit = %v, want about 60", got)
+	}
+	// Solr reports -1 when the heap usage cannot be computed
+	if _, ok := metrics["solr_admin_index_heap_usage_bytes{core=products}"]; ok {
+		t.Error("unexpected index heap usage of -1")
+	}
+}
+
+func TestExporterCoreReloads(t *testing.T) {
+	coresStatus := func(startTimes map[string]string) string {
+		cores := []string{}
+		for core, startTime := range startTimes {
+			cores = append(cores, fmt.Sprintf(`%q: {"name": %q, "startTime": %q, "uptime": 1000, "index": {"numDocs": 0}}`, core, core, startTime))
+		}
+		return `{"initFailures": {}, "status": {` + strings.Join(cores, ",") + `}}`
+	}
+	scrapes := []map[string]string{
+		{"products": "2018-10-01T12:00:00.000Z", "orders": "2018-10-01T12:00:00.000Z"},
+		{"products": "2018-10-01T12:00:00.000Z", "orders": "2018-10-01T12:00:00.000Z"},
+		// products is reloaded and orders unloaded
+		{"products": "2018-10-01T13:00:00.000Z"},
+		{"products": "2018-10-01T13:00:00.000Z"},
+	}
+	scrape := 0
+	server := startFakeSolrCores(t, func() string { return coresStatus(scrapes[scrape]) })
+	defer server.Close()
+
+	exporter := NewExporter(server.URL+"/solr", time.Second, "", http.Client{}, time.Local)
+	var metrics map[string]*dto.Metric
+	for scrape = range scrapes {
+		metrics = collectMetrics(exporter)
+	}
+
+	if got := metrics["solr_core_reloads_total{core=products}"].GetCounter().GetValue(); got != 1 {
+		t.Errorf("products reloads = %v, want 1", got)
+	}
+	if _, ok := metrics["solr_core_reloads_total{core=orders}"]; ok {
+		t.Error("unexpected reloads of the unloaded orders core")
+	}
+	if _, ok := exporter.coreStartTimes["orders"]; ok || len(exporter.coreStartTimes) != 1 {
+		t.Errorf("start times = %v, want only products", exporter.coreStartTimes)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// FreshnessCollector exports the newest value of the configured date fields,
+// telling whether documents still flow into the collections
+type FreshnessCollector struct {
+	newestDocument *prometheus.Desc
+	lag            *prometheus.Desc
+
+	client      http.Client
+	solrBaseURL string
+	freshness   []FreshnessConfig
+}
+
+// NewFreshnessCollector returns a new Collector exposing the freshness of the given collections.
+func NewFreshnessCollector(client http.Client, solrBaseURL string, freshness []FreshnessConfig) (*FreshnessCollector, error) {
+	return &FreshnessCollector{
+		newestDocument: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "collection", "newest_document_timestamp_seconds"),
+			"Newest value of the date field in the collection.",
+			[]string{"collection", "field"},
+			nil,
+		),
+		lag: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "collection", "freshness_lag_seconds"),
+			"Seconds elapsed since the newest value of the date field in the collection.",
+			[]string{"collection", "field"},
+			nil,
+		),
+		client:      client,
+		solrBaseURL: solrBaseURL,
+		freshness:   freshness,
+	}, nil
+}
+
+// getNewestDocument returns the newest value of the field in the collection.
+func (c *FreshnessCollector) getNewestDocument(freshness FreshnessConfig) (time.Time, error) {
+	params := freshness.Params.Values()
+	if _, ok := params["q"]; !ok {
+		params.Set("q", "*:*")
+	}
+	// Solr refuses to sort on a multi-valued field, the documents are sorted
+	// on their highest value instead which needs docValues
+	if freshness.MultiValued {
+		params.Set("sort", fmt.Sprintf("field(%s,max) desc", freshness.Field))
+	} else {
+		params.Set("sort", freshness.Field+" desc")
+	}
+	params.Set("rows", "1")
+	params.Set("fl", freshness.Field)
+
+	response := &QueryResponse{}
+	if err := getJSON(c.client, queryURL(c.solrBaseURL, freshness.Collection, "/select", params), response); err != nil {
+		return time.Time{}, err
+	}
+	if len(response.Response.Docs) == 0 {
+		return time.Time{}, fmt.Errorf("No document with a value for field %s", freshness.Field)
+	}
+
+	return newestDate(response.Response.Docs[0][freshness.Field])
+}
+
+// newestDate returns the date of a single-valued field or the newest date of a
+// multi-valued one.
+func newestDate(value interface{}) (time.Time, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+
+	newest := time.Time{}
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("Value %v is not a date", v)
+		}
+		date, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if date.After(newest) {
+			newest = date
+		}
+	}
+	if newest.IsZero() {
+		return time.Time{}, fmt.Errorf("No date in value %v", value)
+	}
+	return newest, nil
+}
+
+// Update exposes freshness related metrics from solr.
+func (c *FreshnessCollector) Update(ch chan<- prometheus.Metric) error {
+	for _, freshness := range c.freshness {
+		newest, err := c.getNewestDocument(freshness)
+		if err != nil {
+			log.Errorf("Error while querying Solr for freshness of %s in collection %s: %v", freshness.Field, freshness.Collection, err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.newestDocument, prometheus.GaugeValue, float64(newest.UnixNano())/1e9, freshness.Collection, freshness.Field)
+		ch <- prometheus.MustNewConstMetric(c.lag, prometheus.GaugeValue, time.Since(newest).Seconds(), freshness.Collection, freshness.Field)
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *FreshnessCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect freshness metrics: %v", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *FreshnessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.newestDocument
+	ch <- c.lag
+}
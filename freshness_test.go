@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFreshnessCollector(t *testing.T) {
+	createdAt := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	shippedAt := time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/orders/select" {
+			http.NotFound(w, r)
+			return
+		}
+		switch sort := r.URL.Query().Get("sort"); sort {
+		case "created_at desc":
+			fmt.Fprintf(w, `{"responseHeader": {"status": 0}, "response": {"numFound": 2, "docs": [{"created_at": %q}]}}`, createdAt)
+		case "field(shipped_at,max) desc":
+			fmt.Fprintf(w, `{"responseHeader": {"status": 0}, "response": {"numFound": 2, "docs": [{"shipped_at": ["2018-10-01T00:00:00Z", %q]}]}}`, shippedAt)
+		case "shipped_at desc":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"responseHeader": {"status": 400}, "error": {"msg": "can not sort on multivalued field: shipped_at", "code": 400}}`)
+		case "missing_dt desc":
+			fmt.Fprint(w, `{"responseHeader": {"status": 0}, "response": {"numFound": 0, "docs": []}}`)
+		default:
+			t.Errorf("unexpected sort %q", sort)
+		}
+	}))
+	defer server.Close()
+
+	collector, _ := NewFreshnessCollector(*http.DefaultClient, server.URL+"/solr", []FreshnessConfig{
+		{Collection: "orders", Field: "created_at"},
+		{Collection: "orders", Field: "shipped_at", MultiValued: true},
+		{Collection: "orders", Field: "missing_dt"},
+	})
+	metrics := collectMetrics(collector)
+
+	tests := map[string]float64{
+		"solr_collection_freshness_lag_seconds{collection=orders,field=created_at}": 3600,
+		"solr_collection_freshness_lag_seconds{collection=orders,field=shipped_at}": 600,
+	}
+	for metric, want := range tests {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if got := m.GetGauge().GetValue(); math.Abs(got-want) > 60 {
+			t.Errorf("%s = %v, want about %v", metric, got, want)
+		}
+	}
+	if _, ok := metrics["solr_collection_newest_document_timestamp_seconds{collection=orders,field=missing_dt}"]; ok {
+		t.Error("unexpected metric for a field without value")
+	}
+}
+
+func TestNewestDate(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    string
+		wantErr bool
+	}{
+		{"2018-10-02T09:12:51Z", "2018-10-02T09:12:51Z", false},
+		{[]interface{}{"2018-10-02T09:12:51Z", "2018-10-03T00:00:00Z", "2018-10-01T00:00:00Z"}, "2018-10-03T00:00:00Z", false},
+		{[]interface{}{}, "", true},
+		{42.0, "", true},
+		{"yesterday", "", true},
+	}
+	for _, tt := range tests {
+		got, err := newestDate(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newestDate(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.Format(time.RFC3339) != tt.want {
+			t.Errorf("newestDate(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// Java 8 -XX:+PrintGCDetails pause, the duration is the last one of the line
+	// like in [GC (Allocation Failure) [ParNew: 139776K->17472K(157248K), 0.0234 secs] 139776K->25000K(506816K), 0.0235 secs]
+	gcLogPauseDurationRegexp = regexp.MustCompile(`, (\d+[.,]\d+) secs\](?: \[Times:.*)?\s*$`)
+	gcLogPauseCauseRegexp    = regexp.MustCompile(`^(?:Full GC|GC)(?: pause)? \(((?:[^()]|\(\))*)\)`)
+	gcLogGenerationRegexp    = regexp.MustCompile(`(\[[^\[\]:]*: )?(\d+)K->(\d+)K\(\d+K\)`)
+	gcLogG1HeapRegexp        = regexp.MustCompile(`\[Eden: ([\d.,]+[BKMGT])\([^)]*\)->([\d.,]+[BKMGT])\([^)]*\) Survivors: ([\d.,]+[BKMGT])->([\d.,]+[BKMGT]) Heap: ([\d.,]+[BKMGT])\([^)]*\)->([\d.,]+[BKMGT])\(`)
+
+	// Java 9+ -Xlog:gc* pause and heap lines, prefixed by the decorations
+	gcLogUnifiedPauseRegexp   = regexp.MustCompile(`GC\((\d+)\) Pause ([A-Z][a-z]+(?: [A-Z][a-z]+)*)((?: \((?:[^()]|\(\))*\))*)(?: (\d+[BKMGT])->(\d+[BKMGT])\(\d+[BKMGT]\))? (\d+[.,]\d+)ms\s*$`)
+	gcLogUnifiedCauseRegexp   = regexp.MustCompile(`\(((?:[^()]|\(\))*)\)`)
+	gcLogUnifiedYoungRegexp   = regexp.MustCompile(`GC\((\d+)\) (?:ParNew|PSYoungGen|DefNew): (\d+)K->(\d+)K\(`)
+	gcLogUnifiedRegionsRegexp = regexp.MustCompile(`GC\((\d+)\) (?:Eden|Survivor) regions: (\d+)->(\d+)`)
+	gcLogRegionSizeRegexp     = regexp.MustCompile(`(?i)heap region size: (\d+[BKMGT])`)
+
+	// -XX:+PrintGCApplicationStoppedTime or -Xlog:safepoint, and its Java 13+ format
+	gcLogStoppedRegexp   = regexp.MustCompile(`Total time for which application threads were stopped: (\d+[.,]\d+) seconds`)
+	gcLogSafepointRegexp = regexp.MustCompile(`Safepoint "[^"]*", .*Total: (\d+) ns`)
+
+	gcLogPauseBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	// Subtypes of the G1 young pauses, the other parenthesized values are the cause
+	gcLogG1PauseTypes = map[string]bool{"Normal": true, "Mixed": true, "Concurrent Start": true, "Prepare Mixed": true}
+)
+
+// GCLogCollector tails the GC log of the solr JVM, observing the pauses and
+// safepoints and estimating the allocated and promoted bytes from the heap
+// occupancy around the pauses
+type GCLogCollector struct {
+	pauses     *prometheus.HistogramVec
+	safepoints prometheus.Histogram
+	allocated  prometheus.Counter
+	promoted   prometheus.Counter
+
+	tailer *Tailer
+
+	collector     string
+	regionSize    float64
+	lastHeapAfter float64
+	// Java 8 G1 pause waiting for its heap line
+	g1Pause string
+	// Java 8 pause left open, waiting for the line with its duration
+	openPause string
+	// Young generation occupancy logged before the unified pause line
+	gcID        string
+	youngBefore float64
+	youngAfter  float64
+	youngKnown  bool
+}
+
+// NewGCLogCollector returns a new Collector exposing the pauses of the given GC log.
+func NewGCLogCollector(path string) (*GCLogCollector, error) {
+	return &GCLogCollector{
+		pauses: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "gc_log",
+			Name:      "pause_seconds",
+			Help:      "Duration of the GC pauses written to the GC log, by collector, pause type and cause.",
+			Buckets:   gcLogPauseBuckets,
+		}, []string{"collector", "pause", "cause"}),
+		safepoints: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "gc_log",
+			Name:      "safepoint_seconds",
+			Help:      "Time the application threads were stopped at safepoints, requires PrintGCApplicationStoppedTime or -Xlog:safepoint.",
+			Buckets:   gcLogPauseBuckets,
+		}),
+		allocated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "gc_log",
+			Name:      "allocated_bytes_total",
+			Help:      "Bytes allocated between the GC pauses, estimated from the heap occupancy.",
+		}),
+		promoted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "gc_log",
+			Name:      "promoted_bytes_total",
+			Help:      "Bytes promoted to the old generation by the young pauses, estimated from the heap occupancy.",
+		}),
+		tailer:        NewTailer(path),
+		collector:     "unknown",
+		lastHeapAfter: -1,
+	}, nil
+}
+
+// Run tails the GC log, it never returns.
+func (c *GCLogCollector) Run() {
+	c.tailer.Run(time.Second, c.processLine)
+}
+
+// parseGCSize parses a size of the GC log like 1024K, 24.0M or 0.0B to bytes.
+func parseGCSize(size string) (float64, error) {
+	multiplier := 1.0
+	switch size[len(size)-1] {
+	case 'T':
+		multiplier *= 1024
+		fallthrough
+	case 'G':
+		multiplier *= 1024
+		fallthrough
+	case 'M':
+		multiplier *= 1024
+		fallthrough
+	case 'K':
+		multiplier *= 1024
+		fallthrough
+	case 'B':
+		size = size[:len(size)-1]
+	}
+	value, err := strconv.ParseFloat(strings.Replace(size, ",", ".", 1), 64)
+	return value * multiplier, err
+}
+
+// parseGCSeconds parses a duration of the GC log, with a decimal comma in some locales.
+func parseGCSeconds(duration string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(duration, ",", ".", 1), 64)
+}
+
+// gcCollectorName returns the collector logging the line, or an empty string.
+func gcCollectorName(line string) string {
+	switch {
+	case strings.Contains(line, "ParNew") || strings.Contains(line, "CMS") || strings.Contains(line, "Concurrent Mark Sweep"):
+		return "CMS"
+	case strings.Contains(line, "PSYoungGen") || strings.Contains(line, "ParOldGen") || strings.Contains(line, "Using Parallel"):
+		return "Parallel"
+	case strings.Contains(line, "DefNew") || strings.Contains(line, "Tenured") || strings.Contains(line, "Using Serial"):
+		return "Serial"
+	case strings.Contains(line, "G1") || strings.Contains(line, "GC pause (") || strings.Contains(line, "Eden regions"):
+		return "G1"
+	case strings.Contains(line, "Shenandoah"):
+		return "Shenandoah"
+	case strings.Contains(line, "Z Garbage Collector"):
+		return "ZGC"
+	}
+	return ""
+}
+
+// processLine updates the metrics from a line of the GC log.
+func (c *GCLogCollector) processLine(line string) {
+	if collector := gcCollectorName(line); collector != "" {
+		c.collector = collector
+	}
+
+	if match := gcLogStoppedRegexp.FindStringSubmatch(line); match != nil {
+		if seconds, err := parseGCSeconds(match[1]); err == nil {
+			c.safepoints.Observe(seconds)
+		}
+		return
+	}
+	if match := gcLogSafepointRegexp.FindStringSubmatch(line); match != nil {
+		if nanoseconds, err := strconv.ParseFloat(match[1], 64); err == nil {
+			c.safepoints.Observe(nanoseconds / 1e9)
+		}
+		return
+	}
+	if match := gcLogRegionSizeRegexp.FindStringSubmatch(line); match != nil {
+		if size, err := parseGCSize(match[1]); err == nil {
+			c.regionSize = size
+		}
+		return
+	}
+
+	if strings.Contains(line, "GC(") {
+		c.processUnifiedLine(line)
+	} else {
+		c.processJava8Line(line)
+	}
+}
+
+// processJava8Line handles the -XX:+PrintGCDetails format, the G1 pauses have
+// their heap occupancy on a later line and the pauses left open are completed
+// by the first line with a duration.
+func (c *GCLogCollector) processJava8Line(line string) {
+	if match := gcLogG1HeapRegexp.FindStringSubmatch(line); match != nil && c.g1Pause != "" {
+		sizes := make([]float64, 6)
+		for i := range sizes {
+			size, err := parseGCSize(match[i+1])
+			if err != nil {
+				return
+			}
+			sizes[i] = size
+		}
+		c.observeHeap(c.g1Pause, sizes[4], sizes[5], sizes[0]+sizes[2], sizes[1]+sizes[3], true)
+		c.g1Pause = ""
+		return
+	}
+
+	start := strings.Index(line, "[GC ")
+	if full := strings.Index(line, "[Full GC"); full >= 0 && (start < 0 || full < start) {
+		start = full
+	}
+	var pause string
+	switch {
+	case start >= 0 && !strings.HasPrefix(line[start:], "[GC concurrent"):
+		pause = line[start+1:]
+	case start < 0 && c.openPause != "":
+		pause = c.openPause + line
+	default:
+		return
+	}
+	duration := gcLogPauseDurationRegexp.FindStringSubmatch(pause)
+	if duration == nil {
+		// -XX:+PrintTenuringDistribution splits the young pauses after
+		// [ParNew, the duration and occupancy are on a later line
+		if start >= 0 && !strings.HasSuffix(strings.TrimSpace(line), "]") {
+			c.openPause = pause
+		}
+		return
+	}
+	c.openPause = ""
+	seconds, err := parseGCSeconds(duration[1])
+	if err != nil {
+		return
+	}
+
+	cause := ""
+	if match := gcLogPauseCauseRegexp.FindStringSubmatch(pause); match != nil {
+		cause = match[1]
+	}
+	kind := "young"
+	switch {
+	case strings.HasPrefix(pause, "Full GC"):
+		kind = "full"
+	case strings.HasPrefix(pause, "GC pause") && strings.Contains(pause, "(mixed)"):
+		kind = "mixed"
+	case strings.HasPrefix(pause, "GC remark") || cause == "CMS Final Remark":
+		kind, cause = "remark", ""
+	case strings.HasPrefix(pause, "GC cleanup"):
+		kind = "cleanup"
+	case cause == "CMS Initial Mark":
+		kind, cause = "initial_mark", ""
+	}
+	c.pauses.WithLabelValues(c.collector, kind, cause).Observe(seconds)
+
+	if c.collector == "G1" {
+		if kind == "young" || kind == "mixed" || kind == "full" {
+			c.g1Pause = kind
+		}
+		return
+	}
+	heapKnown, youngKnown := false, false
+	var heapBefore, heapAfter, youngBefore, youngAfter float64
+	for _, match := range gcLogGenerationRegexp.FindAllStringSubmatch(pause, -1) {
+		before, _ := strconv.ParseFloat(match[2], 64)
+		after, _ := strconv.ParseFloat(match[3], 64)
+		switch match[1] {
+		case "":
+			if !heapKnown {
+				heapKnown, heapBefore, heapAfter = true, before*1024, after*1024
+			}
+		case "[ParNew: ", "[PSYoungGen: ", "[DefNew: ":
+			youngKnown, youngBefore, youngAfter = true, before*1024, after*1024
+		}
+	}
+	if heapKnown {
+		c.observeHeap(kind, heapBefore, heapAfter, youngBefore, youngAfter, youngKnown)
+	}
+}
+
+// processUnifiedLine handles the -Xlog:gc* format, the young generation
+// occupancy is logged before the pause line of the same GC id.
+func (c *GCLogCollector) processUnifiedLine(line string) {
+	if match := gcLogUnifiedYoungRegexp.FindStringSubmatch(line); match != nil {
+		before, _ := strconv.ParseFloat(match[2], 64)
+		after, _ := strconv.ParseFloat(match[3], 64)
+		c.addYoung(match[1], before*1024, after*1024)
+		return
+	}
+	if match := gcLogUnifiedRegionsRegexp.FindStringSubmatch(line); match != nil {
+		if c.regionSize > 0 {
+			before, _ := strconv.ParseFloat(match[2], 64)
+			after, _ := strconv.ParseFloat(match[3], 64)
+			c.addYoung(match[1], before*c.regionSize, after*c.regionSize)
+		}
+		return
+	}
+
+	match := gcLogUnifiedPauseRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	milliseconds, err := parseGCSeconds(match[6])
+	if err != nil {
+		return
+	}
+
+	kind := strings.ToLower(strings.Replace(match[2], " ", "_", -1))
+	cause := ""
+	for _, value := range gcLogUnifiedCauseRegexp.FindAllStringSubmatch(match[3], -1) {
+		switch {
+		case value[1] == "Mixed":
+			kind = "mixed"
+		case !gcLogG1PauseTypes[value[1]]:
+			cause = value[1]
+		}
+	}
+	c.pauses.WithLabelValues(c.collector, kind, cause).Observe(milliseconds / 1000)
+
+	if match[4] != "" {
+		heapBefore, errBefore := parseGCSize(match[4])
+		heapAfter, errAfter := parseGCSize(match[5])
+		if errBefore == nil && errAfter == nil {
+			youngKnown := c.youngKnown && c.gcID == match[1]
+			c.observeHeap(kind, heapBefore, heapAfter, c.youngBefore, c.youngAfter, youngKnown)
+		}
+	}
+	c.gcID, c.youngKnown = "", false
+}
+
+// addYoung sums the occupancy of the young generation spaces of a GC.
+func (c *GCLogCollector) addYoung(gcID string, before float64, after float64) {
+	if c.gcID != gcID {
+		c.gcID, c.youngBefore, c.youngAfter = gcID, 0, 0
+	}
+	c.youngBefore += before
+	c.youngAfter += after
+	c.youngKnown = true
+}
+
+// observeHeap counts the bytes allocated since the previous pause and the
+// bytes promoted by a young pause, the part of the young generation freed by
+// the pause but still in the heap.
+func (c *GCLogCollector) observeHeap(kind string, heapBefore, heapAfter, youngBefore, youngAfter float64, youngKnown bool) {
+	if c.lastHeapAfter >= 0 && heapBefore > c.lastHeapAfter {
+		c.allocated.Add(heapBefore - c.lastHeapAfter)
+	}
+	c.lastHeapAfter = heapAfter
+
+	if kind == "young" && youngKnown {
+		if promoted := (youngBefore - youngAfter) - (heapBefore - heapAfter); promoted > 0 {
+			c.promoted.Add(promoted)
+		}
+	}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *GCLogCollector) Collect(ch chan<- prometheus.Metric) {
+	c.pauses.Collect(ch)
+	c.safepoints.Collect(ch)
+	c.allocated.Collect(ch)
+	c.promoted.Collect(ch)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *GCLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.pauses.Describe(ch)
+	c.safepoints.Describe(ch)
+	c.allocated.Describe(ch)
+	c.promoted.Describe(ch)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGCLogCollector(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		lines      []string
+		pauses     map[string]float64
+		allocated  float64
+		promoted   float64
+		safepoints uint64
+	}{
+		{
+			name: "java 8 cms",
+			lines: []string{
+				"2018-10-01T12:00:00.123+0000: 12.345: [GC (Allocation Failure) 2018-10-01T12:00:00.123+0000: 12.345: [ParNew: 139776K->17472K(157248K), 0.0234567 secs] 139776K->25000K(506816K), 0.0235678 secs] [Times: user=0.05 sys=0.01, real=0.02 secs]",
+				"2018-10-01T12:00:00.147+0000: 12.369: Total time for which application threads were stopped: 0.0240000 seconds, Stopping threads took: 0.0000500 seconds",
+				"2018-10-01T12:00:01.000+0000: 13.000: [GC (CMS Initial Mark) [1 CMS-initial-mark: 7528K(349568K)] 30000K(506816K), 0.0012345 secs] [Times: user=0.00 sys=0.00, real=0.00 secs]",
+				"2018-10-01T12:00:01.100+0000: 13.100: [CMS-concurrent-mark: 0.100/0.100 secs] [Times: user=0.20 sys=0.00, real=0.10 secs]",
+				// Decimal comma of some locales
+				"2018-10-01T12:00:02.000+0000: 14.000: [GC (Allocation Failure) 2018-10-01T12:00:02.000+0000: 14.000: [ParNew: 157248K->17472K(157248K), 0,0300000 secs] 164776K->30000K(506816K), 0,0300000 secs] [Times: user=0.06 sys=0.00, real=0.03 secs]",
+				"2018-10-01T12:00:03.000+0000: 15.000: [Full GC (System.gc()) 2018-10-01T12:00:03.000+0000: 15.000: [CMS: 12528K->10000K(349568K), 0.1000000 secs] 30000K->10000K(506816K), [Metaspace: 3000K->3000K(1056768K)], 0.1000000 secs] [Times: user=0.10 sys=0.00, real=0.10 secs]",
+			},
+			pauses: map[string]float64{
+				"solr_gc_log_pause_seconds{cause=Allocation Failure,collector=CMS,pause=young}": 0.0535678,
+				"solr_gc_log_pause_seconds{cause=,collector=CMS,pause=initial_mark}":            0.0012345,
+				"solr_gc_log_pause_seconds{cause=System.gc(),collector=CMS,pause=full}":         0.1,
+			},
+			allocated:  139776 * 1024,
+			promoted:   (7528 + 5000) * 1024,
+			safepoints: 1,
+		},
+		{
+			name: "java 8 cms with tenuring distribution",
+			lines: []string{
+				"2018-10-01T12:00:00.123+0000: 12.345: [GC (Allocation Failure) 2018-10-01T12:00:00.123+0000: 12.345: [ParNew",
+				"Desired survivor size 8945664 bytes, new threshold 1 (max 6)",
+				"- age   1:   17888960 bytes,   17888960 total",
+				": 139776K->17472K(157248K), 0.0234567 secs] 139776K->25000K(506816K), 0.0235678 secs] [Times: user=0.05 sys=0.01, real=0.02 secs]",
+				"2018-10-01T12:00:02.000+0000: 14.000: [GC (Allocation Failure) 2018-10-01T12:00:02.000+0000: 14.000: [ParNew",
+				"Desired survivor size 8945664 bytes, new threshold 6 (max 6)",
+				": 157248K->17472K(157248K), 0.0300000 secs] 164776K->30000K(506816K), 0.0300000 secs] [Times: user=0.06 sys=0.00, real=0.03 secs]",
+			},
+			pauses: map[string]float64{
+				"solr_gc_log_pause_seconds{cause=Allocation Failure,collector=CMS,pause=young}": 0.0535678,
+			},
+			allocated: 139776 * 1024,
+			promoted:  (7528 + 5000) * 1024,
+		},
+		{
+			name: "java 8 g1",
+			lines: []string{
+				"2018-10-01T12:00:00.123+0000: 12.345: [GC pause (G1 Evacuation Pause) (young), 0.0123456 secs]",
+				"      [GC Worker Start (ms): Min: 12345.6, Avg: 12345.7, Max: 12345.8, Diff: 0.2]",
+				"   [Eden: 24.0M(24.0M)->0.0B(21.0M) Survivors: 0.0B->3072.0K Heap: 24.0M(256.0M)->4608.0K(256.0M)]",
+				" [Times: user=0.03 sys=0.00, real=0.01 secs]",
+				"2018-10-01T12:00:01.000+0000: 13.000: [GC pause (G1 Evacuation Pause) (mixed), 0.0200000 secs]",
+				"   [Eden: 20.0M(20.0M)->0.0B(20.0M) Survivors: 3072.0K->3072.0K Heap: 40.0M(256.0M)->10.0M(256.0M)]",
+				"2018-10-01T12:00:02.000+0000: 14.000: [GC remark 2018-10-01T12:00:02.000+0000: 14.000: [Finalize Marking, 0.0001000 secs] 2018-10-01T12:00:02.000+0000: 14.000: [GC ref-proc, 0.0002000 secs], 0.0023000 secs]",
+				"2018-10-01T12:00:02.100+0000: 14.100: [GC cleanup 30M->30M(256M), 0.0001000 secs]",
+				"2018-10-01T12:00:02.200+0000: 14.200: [GC concurrent-cleanup-end, 0.0000100 secs]",
+			},
+			pauses: map[string]float64{
+				"solr_gc_log_pause_seconds{cause=G1 Evacuation Pause,collector=G1,pause=young}": 0.0123456,
+				"solr_gc_log_pause_seconds{cause=G1 Evacuation Pause,collector=G1,pause=mixed}": 0.02,
+				"solr_gc_log_pause_seconds{cause=,collector=G1,pause=remark}":                   0.0023,
+				"solr_gc_log_pause_seconds{cause=,collector=G1,pause=cleanup}":                  0.0001,
+			},
+			allocated: 35.5 * 1024 * 1024,
+			promoted:  1.5 * 1024 * 1024,
+		},
+		{
+			name: "unified g1",
+			lines: []string{
+				"[0.005s][info][gc,heap] Heap region size: 1M",
+				"[0.010s][info][gc     ] Using G1",
+				"[1.000s][info][gc,start     ] GC(0) Pause Young (Normal) (G1 Evacuation Pause)",
+				"[1.010s][info][gc,heap      ] GC(0) Eden regions: 24->0(21)",
+				"[1.010s][info][gc,heap      ] GC(0) Survivor regions: 0->3(3)",
+				"[1.010s][info][gc,heap      ] GC(0) Old regions: 0->2",
+				"[1.010s][info][gc           ] GC(0) Pause Young (Normal) (G1 Evacuation Pause) 24M->5M(256M) 12.345ms",
+				"[1.011s][info][safepoint    ] Total time for which application threads were stopped: 0.0124000 seconds, Stopping threads took: 0.0000100 seconds",
+				"[2.000s][info][gc           ] GC(1) Pause Young (Concurrent Start) (G1 Humongous Allocation) 30M->6M(256M) 8.000ms",
+				"[2.100s][info][gc           ] GC(1) Pause Remark 10M->10M(256M) 1.500ms",
+				"[3.000s][info][gc           ] GC(2) Pause Full (System.gc()) 40M->8M(256M) 150.000ms",
+				`[4.000s][info][safepoint    ] Safepoint "G1CollectForAllocation", Time since last: 31434 ns, Reaching safepoint: 2380 ns, At safepoint: 1325413 ns, Total: 1327793 ns`,
+			},
+			pauses: map[string]float64{
+				"solr_gc_log_pause_seconds{cause=G1 Evacuation Pause,collector=G1,pause=young}":     0.012345,
+				"solr_gc_log_pause_seconds{cause=G1 Humongous Allocation,collector=G1,pause=young}": 0.008,
+				"solr_gc_log_pause_seconds{cause=,collector=G1,pause=remark}":                       0.0015,
+				"solr_gc_log_pause_seconds{cause=System.gc(),collector=G1,pause=full}":              0.15,
+			},
+			allocated:  59 * 1024 * 1024,
+			promoted:   2 * 1024 * 1024,
+			safepoints: 2,
+		},
+		{
+			name: "unified cms",
+			lines: []string{
+				"[1.000s][info][gc,heap] GC(0) ParNew: 139776K->17472K(157248K)",
+				"[1.000s][info][gc,heap] GC(0) CMS: 0K->7616K(349568K)",
+				"[1.000s][info][gc     ] GC(0) Pause Young (Allocation Failure) 136M->24M(494M) 23.456ms",
+				"[2.000s][info][gc     ] GC(1) Pause Initial Mark 30M->30M(494M) 1.000ms",
+			},
+			pauses: map[string]float64{
+				"solr_gc_log_pause_seconds{cause=Allocation Failure,collector=CMS,pause=young}": 0.023456,
+				"solr_gc_log_pause_seconds{cause=,collector=CMS,pause=initial_mark}":            0.001,
+			},
+			allocated: 6 * 1024 * 1024,
+			promoted:  7616 * 1024,
+		},
+	} {
+		collector, err := NewGCLogCollector("/nonexistent/solr_gc.log")
+		if err != nil {
+			t.Fatalf("creating collector: %v", err)
+		}
+		for _, line := range test.lines {
+			collector.processLine(line)
+		}
+
+		metrics := collectMetrics(collector)
+		for metric, want := range test.pauses {
+			histogram := metrics[metric].GetHistogram()
+			if histogram.GetSampleCount() == 0 || math.Abs(histogram.GetSampleSum()-want) > 1e-9 {
+				t.Errorf("%s: %s sum = %v, want %v", test.name, metric, histogram.GetSampleSum(), want)
+			}
+		}
+		if got := metrics["solr_gc_log_allocated_bytes_total{}"].GetCounter().GetValue(); got != test.allocated {
+			t.Errorf("%s: allocated = %v, want %v", test.name, got, test.allocated)
+		}
+		if got := metrics["solr_gc_log_promoted_bytes_total{}"].GetCounter().GetValue(); got != test.promoted {
+			t.Errorf("%s: promoted = %v, want %v", test.name, got, test.promoted)
+		}
+		if got := metrics["solr_gc_log_safepoint_seconds{}"].GetHistogram().GetSampleCount(); got != test.safepoints {
+			t.Errorf("%s: safepoints = %v, want %v", test.name, got, test.safepoints)
+		}
+		if len(metrics) != len(test.pauses)+3 {
+			t.Errorf("%s: got %d metrics, want %d: %v", test.name, len(metrics), len(test.pauses)+3, metrics)
+		}
+	}
+}
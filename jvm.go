@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/prometheus/client_golang/prometheus"
@@ -58,9 +60,16 @@ type JVMCollector struct {
 	threadsTimedWaitingCount *prometheus.Desc
 	threadsWaitingCount      *prometheus.Desc
 
+	startTime *prometheus.Desc
+	uptime    *prometheus.Desc
+	restarts  prometheus.Counter
+
 	client      http.Client
 	jvmURL      string
 	solrInfoURL string
+
+	mutex         sync.Mutex
+	lastStartTime time.Time
 }
 
 // NewJVMCollector returns a new Collector exposing solr jvm statistics.
@@ -288,6 +297,26 @@ func NewJVMCollector(client http.Client, solrBaseURL string) (*JVMCollector, err
 			[]string{},
 			nil,
 		),
+
+		startTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "jvm", "start_time_seconds"),
+			"JVM start time since unix epoch in seconds.",
+			[]string{},
+			nil,
+		),
+		uptime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "jvm", "uptime_seconds"),
+			"JVM uptime in seconds.",
+			[]string{},
+			nil,
+		),
+		restarts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "jvm",
+			Name:      "restarts_total",
+			Help:      "Number of JVM restarts observed by the exporter.",
+		}),
+
 		client:      client,
 		jvmURL:      jvmURL,
 		solrInfoURL: solrInfoURL,
@@ -296,27 +325,24 @@ func NewJVMCollector(client http.Client, solrBaseURL string) (*JVMCollector, err
 
 // Update exposes jvm related metrics from solr.
 func (c *JVMCollector) Update(ch chan<- prometheus.Metric) error {
-	resp, err := c.client.Get(c.solrInfoURL)
+	infoSystem, err := getInfoSystem(c.client, c.solrInfoURL)
 	if err != nil {
-		return fmt.Errorf("Error while querying Solr for infos : %v", err)
+		return err
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	semanticVersion, err := infoSystem.SolrVersion()
 	if err != nil {
-		return fmt.Errorf("Failed to read infos response body: %v", err)
+		return err
 	}
 
-	infoSystem := &InfoSystem{}
-	err = json.Unmarshal(body, infoSystem)
-	if err != nil {
-		return fmt.Errorf("Failed to unmarshal solr infos JSON into struct: %v", err)
+	if startTime, err := time.Parse(time.RFC3339, infoSystem.JVM.JMX.StartTime); err == nil {
+		if !c.lastStartTime.IsZero() && startTime.After(c.lastStartTime) {
+			c.restarts.Inc()
+		}
+		c.lastStartTime = startTime
+		ch <- prometheus.MustNewConstMetric(c.startTime, prometheus.GaugeValue, float64(startTime.UnixNano())/1e9)
+		ch <- prometheus.MustNewConstMetric(c.uptime, prometheus.GaugeValue, float64(infoSystem.JVM.JMX.UpTimeMS)/1000)
 	}
 
-	semanticVersion, err := semver.Make(infoSystem.Lucene.SolrVersion)
-	if err != nil {
-		return fmt.Errorf("Error parsing version string: %v", err)
-	}
 	semanticVersionSolr6, err := semver.Make("6.0.0")
 	semanticVersionSolr7, err := semver.Make("7.0.0")
 	// No jvm stats for solr < 6
@@ -324,13 +350,13 @@ func (c *JVMCollector) Update(ch chan<- prometheus.Metric) error {
 		return nil
 	}
 
-	resp, err = c.client.Get(c.jvmURL)
+	resp, err := c.client.Get(c.jvmURL)
 	if err != nil {
 		return fmt.Errorf("Error while querying Solr for jvm stats: %v", err)
 	}
 	defer resp.Body.Close()
 
-	body, err = ioutil.ReadAll(resp.Body)
+	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("Failed to read jvm stats response body: %v", err)
 	}
@@ -440,9 +466,13 @@ func (c *JVMCollector) Update(ch chan<- prometheus.Metric) error {
 
 // Collect implements the prometheus.Collector interface.
 func (c *JVMCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect the last start time from concurrent collects.
+	defer c.mutex.Unlock()
+
 	if err := c.Update(ch); err != nil {
 		log.Errorf("Failed to collect metrics: %v", err)
 	}
+	ch <- c.restarts
 }
 
 // Describe implements the prometheus.Collector interface.
@@ -488,4 +518,8 @@ func (c *JVMCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.threadsTerminatedCount
 	ch <- c.threadsTimedWaitingCount
 	ch <- c.threadsWaitingCount
+
+	ch <- c.startTime
+	ch <- c.uptime
+	ch <- c.restarts.Desc()
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// Standard solr.log layout: date, level, thread, MDC, logger and message,
+	// the thread names can contain parentheses like main-SendThread(zk1:2181).
+	logLineRegexp       = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{3} (\w+)\s+\(.*?\) \[([^\]]*)\] (\S+) (.*)$`)
+	logMDCRegexp        = regexp.MustCompile(`\b(\w):(\S+)`)
+	logSlowCoreRegexp   = regexp.MustCompile(`^slow:\s+\[([^\]]+)\]`)
+	logSlowPathRegexp   = regexp.MustCompile(`\bpath=(\S+)`)
+	logSlowQTimeRegexp  = regexp.MustCompile(`\bQTime=(\d+)`)
+	logSlowQTimeBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60}
+)
+
+// LogFileCollector tails the solr.log file of a local solr, counting the
+// errors and warnings and observing the QTime of the slow requests
+type LogFileCollector struct {
+	events    *prometheus.CounterVec
+	slowQTime *prometheus.HistogramVec
+
+	tailer *Tailer
+}
+
+// NewLogFileCollector returns a new Collector exposing the events of the given solr.log file.
+func NewLogFileCollector(path string) (*LogFileCollector, error) {
+	return &LogFileCollector{
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "log_file",
+			Name:      "events_total",
+			Help:      "Number of ERROR and WARN events written to the solr log file, by logger and core.",
+		}, []string{"level", "logger", "core"}),
+		slowQTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "log_file",
+			Name:      "slow_query_qtime_seconds",
+			Help:      "Query time of the slow requests written to the solr log file.",
+			Buckets:   logSlowQTimeBuckets,
+		}, []string{"collection", "handler"}),
+		tailer: NewTailer(path),
+	}, nil
+}
+
+// Run tails the log file, it never returns.
+func (c *LogFileCollector) Run() {
+	c.tailer.Run(time.Second, c.processLine)
+}
+
+// processLine updates the metrics from a line of the log file, the lines of
+// stack traces are ignored.
+func (c *LogFileCollector) processLine(line string) {
+	match := logLineRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	level, mdc, logger, message := match[1], match[2], match[3], match[4]
+
+	mdcValues := map[string]string{}
+	for _, value := range logMDCRegexp.FindAllStringSubmatch(mdc, -1) {
+		mdcValues[value[1]] = value[2]
+	}
+
+	if strings.HasPrefix(message, "slow:") {
+		c.processSlowRequest(mdcValues, message)
+	}
+
+	if level == "ERROR" || level == "WARN" {
+		c.events.WithLabelValues(level, logger, mdcValues["x"]).Inc()
+	}
+}
+
+// processSlowRequest observes the QTime of a slow request line like
+// slow: [products] webapp=/solr path=/select params={q=*:*} hits=10 status=0 QTime=1500
+func (c *LogFileCollector) processSlowRequest(mdcValues map[string]string, message string) {
+	qtime := logSlowQTimeRegexp.FindStringSubmatch(message)
+	path := logSlowPathRegexp.FindStringSubmatch(message)
+	if qtime == nil || path == nil {
+		return
+	}
+	milliseconds, err := strconv.ParseFloat(qtime[1], 64)
+	if err != nil {
+		return
+	}
+
+	// Standalone cores have no collection in the MDC.
+	collection := mdcValues["c"]
+	if collection == "" {
+		if core := logSlowCoreRegexp.FindStringSubmatch(message); core != nil {
+			collection = core[1]
+		}
+	}
+	c.slowQTime.WithLabelValues(collection, path[1]).Observe(milliseconds / 1000)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *LogFileCollector) Collect(ch chan<- prometheus.Metric) {
+	c.events.Collect(ch)
+	c.slowQTime.Collect(ch)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *LogFileCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.events.Describe(ch)
+	c.slowQTime.Describe(ch)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLogFileCollector(t *testing.T) {
+	collector, err := NewLogFileCollector("/nonexistent/solr.log")
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	for _, line := range []string{
+		"2018-10-01 12:00:00.123 INFO  (qtp1234-15) [c:products s:shard1 r:core_node2 x:products_shard1_replica_n1] o.a.s.c.S.Request [products_shard1_replica_n1]  webapp=/solr path=/select params={q=*:*} hits=10 status=0 QTime=12",
+		"2018-10-01 12:00:01.123 WARN  (qtp1234-16) [c:products s:shard1 r:core_node2 x:products_shard1_replica_n1] o.a.s.c.S.SlowRequest slow: [products_shard1_replica_n1]  webapp=/solr path=/select params={q=*:*} hits=10 status=0 QTime=1500",
+		"2018-10-01 12:00:02.123 INFO  (qtp1234-17) [   x:orders] o.a.s.c.S.Request slow: [orders]  webapp=/solr path=/update params={} status=0 QTime=3000",
+		"2018-10-01 12:00:03.123 ERROR (qtp1234-18) [   x:orders] o.a.s.h.RequestHandlerBase org.apache.solr.common.SolrException: undefined field foo",
+		"\tat org.apache.solr.schema.IndexSchema.getField(IndexSchema.java:1234)",
+		"2018-10-01 12:00:04.123 ERROR (main) [   ] o.a.s.c.CoreContainer Error waiting for SolrCore to be loaded on startup",
+		"2018-10-01 12:00:05.123 WARN  (main-SendThread(zk1:2181)) [   ] o.a.z.ClientCnxn Client session timed out, have not heard from server in 10008ms for sessionid 0x0",
+	} {
+		collector.processLine(line)
+	}
+
+	metrics := collectMetrics(collector)
+	for metric, want := range map[string]float64{
+		"solr_log_file_events_total{core=products_shard1_replica_n1,level=WARN,logger=o.a.s.c.S.SlowRequest}": 1,
+		"solr_log_file_events_total{core=orders,level=ERROR,logger=o.a.s.h.RequestHandlerBase}":               1,
+		"solr_log_file_events_total{core=,level=ERROR,logger=o.a.s.c.CoreContainer}":                          1,
+		"solr_log_file_events_total{core=,level=WARN,logger=o.a.z.ClientCnxn}":                                1,
+	} {
+		if got := metrics[metric].GetCounter().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	for metric, want := range map[string]float64{
+		"solr_log_file_slow_query_qtime_seconds{collection=products,handler=/select}": 1.5,
+		"solr_log_file_slow_query_qtime_seconds{collection=orders,handler=/update}":   3,
+	} {
+		histogram := metrics[metric].GetHistogram()
+		if histogram.GetSampleCount() != 1 || math.Abs(histogram.GetSampleSum()-want) > 1e-9 {
+			t.Errorf("%s count = %d, sum = %v, want a single %v", metric, histogram.GetSampleCount(), histogram.GetSampleSum(), want)
+		}
+	}
+	if len(metrics) != 6 {
+		t.Errorf("got %d metrics, want 6: %v", len(metrics), metrics)
+	}
+}
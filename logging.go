@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var loggingPath = "/admin/info/logging?wt=json&since=%d"
+
+// Maximum number of distinct exception label values, the other exceptions
+// are counted as "other".
+const loggingMaxExceptions = 50
+
+// LoggingCollector counts the events of the solr log watcher. The log watcher
+// only keeps the last events in memory, it is polled incrementally on every
+// scrape from the timestamp of the last event seen.
+type LoggingCollector struct {
+	events *prometheus.CounterVec
+
+	client     http.Client
+	loggingURL string
+
+	mutex      sync.Mutex
+	last       int64
+	started    bool
+	exceptions map[string]bool
+}
+
+// NewLoggingCollector returns a new Collector exposing solr log events counters.
+func NewLoggingCollector(client http.Client, solrBaseURL string) (*LoggingCollector, error) {
+	loggingURL := fmt.Sprintf("%s%s", solrBaseURL, loggingPath)
+	return &LoggingCollector{
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "log",
+			Name:      "events_total",
+			Help:      "Number of log events by level, logger and exception class.",
+		}, []string{"level", "logger", "exception"}),
+		client:     client,
+		loggingURL: loggingURL,
+		exceptions: map[string]bool{},
+	}, nil
+}
+
+// exceptionLabel returns the bounded exception label value of a log event.
+func (c *LoggingCollector) exceptionLabel(trace string, message string) string {
+	matches := exceptionClassRegexp.FindStringSubmatch(trace)
+	if matches == nil {
+		matches = exceptionClassRegexp.FindStringSubmatch(message)
+	}
+	if matches == nil {
+		return "none"
+	}
+	exception := matches[1]
+	if !c.exceptions[exception] {
+		if len(c.exceptions) >= loggingMaxExceptions {
+			return "other"
+		}
+		c.exceptions[exception] = true
+	}
+	return exception
+}
+
+// Update counts the log events since the last update.
+func (c *LoggingCollector) Update() error {
+	history := &LoggingHistory{}
+	if err := getJSON(c.client, fmt.Sprintf(c.loggingURL, c.last), history); err != nil {
+		return fmt.Errorf("Error while querying Solr for log events: %v", err)
+	}
+
+	// The events buffered before the first poll predate the exporter, they
+	// would be counted again on every restart of the exporter.
+	if c.started {
+		for _, event := range history.History.Docs {
+			c.events.WithLabelValues(event.Level, event.Logger, c.exceptionLabel(event.Trace, event.Message)).Inc()
+		}
+	}
+	c.started = true
+	// Also goes back in time when solr restarted with an empty log watcher.
+	c.last = history.Info.Last
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *LoggingCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects.
+	defer c.mutex.Unlock()
+
+	if err := c.Update(); err != nil {
+		log.Errorf("Failed to collect log events: %v", err)
+	}
+	c.events.Collect(ch)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *LoggingCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.events.Describe(ch)
+}
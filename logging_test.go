@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoggingCollector(t *testing.T) {
+	type event map[string]string
+	polls := []struct {
+		last int64
+		docs []event
+	}{
+		// Buffered before the exporter started
+		{last: 100, docs: []event{
+			{"level": "ERROR", "logger": "org.apache.solr.core.SolrCore", "message": "old", "trace": "org.apache.solr.common.SolrException: old"},
+		}},
+		{last: 200, docs: []event{
+			{"level": "WARN", "logger": "org.apache.solr.core.SolrCore", "message": "slow commit"},
+			{"level": "ERROR", "logger": "org.apache.solr.handler.RequestHandlerBase", "message": "org.apache.solr.common.SolrException: undefined field foo",
+				"trace": "org.apache.solr.common.SolrException: undefined field foo\n\tat org.apache.solr.schema.IndexSchema.getField(IndexSchema.java:1234)"},
+		}},
+	}
+	// More distinct exceptions than the cap
+	for i := 0; i < loggingMaxExceptions+2; i++ {
+		polls[1].docs = append(polls[1].docs, event{
+			"level": "ERROR", "logger": "org.apache.solr.servlet.HttpSolrCall", "message": "failed",
+			"trace": fmt.Sprintf("com.example.Custom%dException: failed", i),
+		})
+	}
+
+	since := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		poll := polls[len(since)]
+		since = append(since, r.URL.Query().Get("since"))
+		docs, _ := json.Marshal(poll.docs)
+		fmt.Fprintf(w, `{"responseHeader": {"status": 0, "QTime": 0}, "info": {"buffer": 50, "last": %d, "levels": ["ERROR", "WARN"]}, "history": {"numFound": %d, "start": 0, "docs": %s}}`,
+			poll.last, len(poll.docs), docs)
+	}))
+	defer server.Close()
+
+	collector, err := NewLoggingCollector(*http.DefaultClient, server.URL+"/solr")
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	if metrics := collectMetrics(collector); len(metrics) != 0 {
+		t.Errorf("got %d metrics after the first poll, want none: %v", len(metrics), metrics)
+	}
+	metrics := collectMetrics(collector)
+
+	if len(since) != 2 || since[0] != "0" || since[1] != "100" {
+		t.Errorf("polled since %v, want [0 100]", since)
+	}
+	for metric, want := range map[string]float64{
+		"solr_log_events_total{exception=none,level=WARN,logger=org.apache.solr.core.SolrCore}":                        1,
+		"solr_log_events_total{exception=SolrException,level=ERROR,logger=org.apache.solr.handler.RequestHandlerBase}": 1,
+		"solr_log_events_total{exception=Custom0Exception,level=ERROR,logger=org.apache.solr.servlet.HttpSolrCall}":    1,
+		// SolrException took one of the slots
+		"solr_log_events_total{exception=other,level=ERROR,logger=org.apache.solr.servlet.HttpSolrCall}": 3,
+	} {
+		if got := metrics[metric].GetCounter().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	if len(collector.exceptions) != loggingMaxExceptions {
+		t.Errorf("got %d exceptions, want %d", len(collector.exceptions), loggingMaxExceptions)
+	}
+	if len(metrics) != loggingMaxExceptions+2 {
+		t.Errorf("got %d metrics, want %d", len(metrics), loggingMaxExceptions+2)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// The default show style returns both the index summary and the per field
+// statistics, numTerms=0 skips the expensive top terms computation.
+var lukePath = "/admin/luke?numTerms=0&wt=json"
+
+// LukeCollector collects field level index metrics from the luke request
+// handler. Luke is expensive so solr is only queried once per interval, the
+// last results being exported in between.
+type LukeCollector struct {
+	indexedFields         *prometheus.Desc
+	dynamicFieldInstances *prometheus.Desc
+	fieldDocs             *prometheus.Desc
+	lastModified          *prometheus.Desc
+
+	client        http.Client
+	adminCoresURL string
+	lukeURL       string
+	fields        map[string]bool
+	interval      time.Duration
+
+	mutex      sync.Mutex
+	lastUpdate time.Time
+	metrics    []prometheus.Metric
+}
+
+// NewLukeCollector returns a new Collector exposing luke statistics for the given fields.
+func NewLukeCollector(client http.Client, solrBaseURL string, fields []string, interval time.Duration) (*LukeCollector, error) {
+	adminCoresURL := fmt.Sprintf("%s%s", solrBaseURL, adminCoresPath)
+	lukeURL := fmt.Sprintf("%s/%s%s", solrBaseURL, "%s", lukePath)
+	allowedFields := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		allowedFields[field] = true
+	}
+	return &LukeCollector{
+		indexedFields: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "luke", "indexed_fields"),
+			"Number of fields in the core index.",
+			[]string{"core"},
+			nil,
+		),
+		dynamicFieldInstances: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "luke", "dynamic_field_instances"),
+			"Number of fields in the core index instantiated from a dynamic field.",
+			[]string{"core", "dynamic_field"},
+			nil,
+		),
+		fieldDocs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "luke", "field_docs"),
+			"Number of documents with a value for the field.",
+			[]string{"core", "field"},
+			nil,
+		),
+		lastModified: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "luke", "index_last_modified_timestamp_seconds"),
+			"Last modification time of the core index.",
+			[]string{"core"},
+			nil,
+		),
+		client:        client,
+		adminCoresURL: adminCoresURL,
+		lukeURL:       lukeURL,
+		fields:        allowedFields,
+		interval:      interval,
+	}, nil
+}
+
+// Update exposes luke related metrics from solr.
+func (c *LukeCollector) Update(ch chan<- prometheus.Metric) error {
+	adminCoresStatus, err := getAdminCoresStatus(c.client, c.adminCoresURL)
+	if err != nil {
+		return err
+	}
+
+	for _, coreName := range getCoresFromStatus(adminCoresStatus) {
+		if isExcludedCore(coreName) {
+			continue
+		}
+
+		lukeInfo := &LukeInfo{}
+		if err := getJSON(c.client, fmt.Sprintf(c.lukeURL, coreName), lukeInfo); err != nil {
+			log.Errorf("Error while querying Solr for luke stats of core %s: %v", coreName, err)
+			continue
+		}
+
+		dynamicFields := map[string]int{}
+		for name, field := range lukeInfo.Fields {
+			if field.DynamicBase != "" {
+				dynamicFields[field.DynamicBase]++
+			}
+			if c.fields[name] {
+				ch <- prometheus.MustNewConstMetric(c.fieldDocs, prometheus.GaugeValue, float64(field.Docs), coreName, name)
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.indexedFields, prometheus.GaugeValue, float64(len(lukeInfo.Fields)), coreName)
+		for dynamicBase, count := range dynamicFields {
+			ch <- prometheus.MustNewConstMetric(c.dynamicFieldInstances, prometheus.GaugeValue, float64(count), coreName, dynamicBase)
+		}
+		if lastModified, err := time.Parse(time.RFC3339, lukeInfo.Index.LastModified); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.lastModified, prometheus.GaugeValue, float64(lastModified.UnixNano())/1e9, coreName)
+		}
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *LukeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if time.Since(c.lastUpdate) >= c.interval {
+		metricsCh := make(chan prometheus.Metric)
+		done := make(chan struct{})
+		metrics := []prometheus.Metric{}
+		go func() {
+			for metric := range metricsCh {
+				metrics = append(metrics, metric)
+			}
+			close(done)
+		}()
+		err := c.Update(metricsCh)
+		close(metricsCh)
+		<-done
+
+		// A failed attempt also waits for the next interval, the previous
+		// results being exported in between
+		c.lastUpdate = time.Now()
+		if err != nil {
+			log.Errorf("Failed to collect luke metrics: %v", err)
+		} else {
+			c.metrics = metrics
+		}
+	}
+
+	for _, metric := range c.metrics {
+		ch <- metric
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *LukeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.indexedFields
+	ch <- c.dynamicFieldInstances
+	ch <- c.fieldDocs
+	ch <- c.lastModified
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLukeCollectorInterval(t *testing.T) {
+	var requests, failing int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/solr/admin/cores":
+			atomic.AddInt32(&requests, 1)
+			if atomic.LoadInt32(&failing) == 1 {
+				http.Error(w, "Server Error", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, `{"status": {"products": {}}}`)
+		case "/solr/products/admin/luke":
+			fmt.Fprint(w, `{"index": {"lastModified": "2018-10-02T09:12:51.235Z"},
+				"fields": {"id": {"type": "string", "docs": 42}, "name_s": {"type": "string", "dynamicBase": "*_s", "docs": 12}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	collector, _ := NewLukeCollector(*http.DefaultClient, server.URL+"/solr", []string{"id"}, time.Hour)
+	collect := func(step string) {
+		metrics := collectMetrics(collector)
+		if got := metrics["solr_luke_field_docs{core=products,field=id}"].GetGauge().GetValue(); got != 42 {
+			t.Errorf("%s: field docs = %v, want 42", step, got)
+		}
+		if got := metrics["solr_luke_dynamic_field_instances{core=products,dynamic_field=*_s}"].GetGauge().GetValue(); got != 1 {
+			t.Errorf("%s: dynamic field instances = %v, want 1", step, got)
+		}
+	}
+
+	collect("first scrape")
+	collect("within the interval")
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("got %d requests within the interval, want 1", got)
+	}
+
+	// Once the interval elapsed a failure keeps the previous results and waits
+	// for the next interval before trying again
+	atomic.StoreInt32(&failing, 1)
+	collector.lastUpdate = time.Now().Add(-2 * time.Hour)
+	collect("failed scrape")
+	collect("after the failed scrape")
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("got %d requests after the failure, want 2", got)
+	}
+}
@@ -29,13 +29,36 @@ const (
 )
 
 var (
-	listenAddress    = kingpin.Flag("web.listen-address", "Address to listen on for web interface and telemetry.").Default(":9231").String()
-	metricsPath      = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
-	solrURI          = kingpin.Flag("solr.address", "URI on which to scrape Solr.").Default("http://localhost:8983").String()
-	solrContextPath  = kingpin.Flag("solr.context-path", "Solr webapp context path.").Default("/solr").String()
-	solrExcludedCore = kingpin.Flag("solr.excluded-core", "Regex to exclude core from monitoring").Default("").String()
-	solrTimeout      = kingpin.Flag("solr.timeout", "Timeout for trying to get stats from Solr.").Default("5s").Duration()
-	solrPidFile      = kingpin.Flag("solr.pid-file", "").Default(pidFileHelpText).String()
+	listenAddress          = kingpin.Flag("web.listen-address", "Address to listen on for web interface and telemetry.").Default(":9231").String()
+	metricsPath            = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
+	solrURI                = kingpin.Flag("solr.address", "URI on which to scrape Solr.").Default("http://localhost:8983").String()
+	solrContextPath        = kingpin.Flag("solr.context-path", "Solr webapp context path.").Default("/solr").String()
+	solrExcludedCore       = kingpin.Flag("solr.excluded-core", "Regex to exclude core from monitoring").Default("").String()
+	solrTimeout            = kingpin.Flag("solr.timeout", "Timeout for trying to get stats from Solr.").Default("5s").Duration()
+	solrPidFile            = kingpin.Flag("solr.pid-file", "").Default(pidFileHelpText).String()
+	solrTimezone           = kingpin.Flag("solr.timezone", "Timezone of the Solr JVM, like Europe/Paris, used to parse the replication and dataimport dates.").Default("Local").String()
+	solrLogFile            = kingpin.Flag("solr.log-file", "Path to the solr.log file to tail for errors and slow requests, when running next to Solr.").Default("").String()
+	solrRequestLog         = kingpin.Flag("solr.request-log", "Path or glob pattern of the jetty request log to tail for request latencies, when running next to Solr.").Default("").String()
+	solrRequestLogNetworks = kingpin.Flag("solr.request-log.client-networks", "Comma separated list of CIDR networks to bucket the request log clients by.").Default("").String()
+	solrGCLog              = kingpin.Flag("solr.gc-log", "Path or glob pattern of the JVM GC log to tail for pause times, when running next to Solr.").Default("").String()
+	zookeeperAddress       = kingpin.Flag("zookeeper.address", "Comma separated list of zookeeper servers to probe with the mntr, ruok and srvr commands.").Default("").String()
+	zookeeperJuteMaxBuffer = kingpin.Flag("zookeeper.jute-maxbuffer", "Zookeeper jute.maxbuffer in bytes, used to compute the znode size ratio.").Default("1048575").Int64()
+	zookeeperZnodeRatio    = kingpin.Flag("zookeeper.znode-warning-ratio", "Ratio of jute.maxbuffer above which a znode is reported as oversized.").Default("0.8").Float64()
+	collectorCluster       = kingpin.Flag("collector.cluster", "Enable the SolrCloud cluster status collector.").Default("false").Bool()
+	collectorOverseer      = kingpin.Flag("collector.overseer", "Enable the SolrCloud overseer status collector, queued on the overseer work queue: enable it on one exporter only.").Default("false").Bool()
+	collectorZookeeper     = kingpin.Flag("collector.zookeeper", "Enable the zookeeper status collector (Solr >= 7.5).").Default("false").Bool()
+	collectorZnode         = kingpin.Flag("collector.znode", "Enable the zookeeper znode size collector.").Default("false").Bool()
+	collectorZnodeInterval = kingpin.Flag("collector.znode.configs-interval", "Interval between two walks of the configset files, the last sizes are exported in between.").Default("10m").Duration()
+	collectorReplication   = kingpin.Flag("collector.replication", "Enable the master/slave replication collector.").Default("false").Bool()
+	collectorDivergence    = kingpin.Flag("collector.divergence", "Enable the SolrCloud replica divergence collector, querying every node hosting a replica.").Default("false").Bool()
+	collectorSegments      = kingpin.Flag("collector.segments", "Enable the per core segments collector.").Default("false").Bool()
+	collectorLuke          = kingpin.Flag("collector.luke", "Enable the luke field statistics collector.").Default("false").Bool()
+	collectorLukeInterval  = kingpin.Flag("collector.luke.interval", "Interval between two luke requests, the last results are exported in between.").Default("5m").Duration()
+	collectorLukeFields    = kingpin.Flag("collector.luke.fields", "Comma separated list of fields to export the document count of.").Default("").String()
+	collectorPing          = kingpin.Flag("collector.ping", "Enable the per core ping collector.").Default("false").Bool()
+	collectorThreads       = kingpin.Flag("collector.threads", "Enable the thread dump collector.").Default("false").Bool()
+	collectorLogging       = kingpin.Flag("collector.logging", "Enable the log watcher events collector.").Default("false").Bool()
+	configFile             = kingpin.Flag("config.file", "Path to the JSON configuration file declaring the probes, queries, freshness checks and canary.").Default("").String()
 )
 
 func main() {
@@ -64,7 +87,12 @@ func main() {
 
 	solrBaseURL := fmt.Sprintf("%s%s", *solrURI, *solrContextPath)
 
-	exporter := NewExporter(solrBaseURL, *solrTimeout, *solrExcludedCore, *client)
+	solrLocation, err := time.LoadLocation(*solrTimezone)
+	if err != nil {
+		log.Fatalf("Invalid solr timezone %q: %v", *solrTimezone, err)
+	}
+
+	exporter := NewExporter(solrBaseURL, *solrTimeout, *solrExcludedCore, *client, solrLocation)
 	prometheus.MustRegister(exporter)
 	prometheus.MustRegister(version.NewCollector("solr_exporter"))
 
@@ -74,6 +102,148 @@ func main() {
 	}
 	prometheus.MustRegister(jvmExporter)
 
+	if *collectorCluster {
+		clusterExporter, err := NewClusterCollector(*client, solrBaseURL)
+		if err != nil {
+			log.Errorf("Failed to create cluster metrics collector: %v", err)
+		}
+		prometheus.MustRegister(clusterExporter)
+	}
+
+	if *collectorOverseer {
+		overseerExporter, err := NewOverseerCollector(*client, solrBaseURL)
+		if err != nil {
+			log.Errorf("Failed to create overseer metrics collector: %v", err)
+		}
+		prometheus.MustRegister(overseerExporter)
+	}
+
+	if *collectorZookeeper {
+		zookeeperExporter, err := NewZookeeperCollector(*client, solrBaseURL)
+		if err != nil {
+			log.Errorf("Failed to create zookeeper metrics collector: %v", err)
+		}
+		prometheus.MustRegister(zookeeperExporter)
+	}
+
+	if *collectorZnode {
+		znodeExporter, err := NewZnodeCollector(*client, solrBaseURL, *zookeeperJuteMaxBuffer, *zookeeperZnodeRatio, *collectorZnodeInterval)
+		if err != nil {
+			log.Errorf("Failed to create znode metrics collector: %v", err)
+		}
+		prometheus.MustRegister(znodeExporter)
+	}
+
+	if *collectorLuke {
+		lukeFields := []string{}
+		for _, field := range strings.Split(*collectorLukeFields, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				lukeFields = append(lukeFields, field)
+			}
+		}
+		lukeExporter, err := NewLukeCollector(*client, solrBaseURL, lukeFields, *collectorLukeInterval)
+		if err != nil {
+			log.Errorf("Failed to create luke metrics collector: %v", err)
+		}
+		prometheus.MustRegister(lukeExporter)
+	}
+
+	if *zookeeperAddress != "" {
+		mntrExporter, err := NewZookeeperMntrCollector(parseZookeeperServers(*zookeeperAddress), *solrTimeout)
+		if err != nil {
+			log.Errorf("Failed to create zookeeper mntr metrics collector: %v", err)
+		}
+		prometheus.MustRegister(mntrExporter)
+	}
+
+	if *collectorReplication {
+		replicationExporter, err := NewReplicationCollector(*client, solrBaseURL, *solrTimezone)
+		if err != nil {
+			log.Fatalf("Failed to create replication metrics collector: %v", err)
+		}
+		prometheus.MustRegister(replicationExporter)
+	}
+
+	if *collectorDivergence {
+		divergenceExporter, err := NewDivergenceCollector(*client, solrBaseURL)
+		if err != nil {
+			log.Errorf("Failed to create replica divergence metrics collector: %v", err)
+		}
+		prometheus.MustRegister(divergenceExporter)
+	}
+
+	if *collectorSegments {
+		segmentsExporter, err := NewSegmentsCollector(*client, solrBaseURL)
+		if err != nil {
+			log.Errorf("Failed to create segments metrics collector: %v", err)
+		}
+		prometheus.MustRegister(segmentsExporter)
+	}
+
+	if *collectorPing {
+		pingExporter, err := NewPingCollector(*client, solrBaseURL)
+		if err != nil {
+			log.Errorf("Failed to create ping metrics collector: %v", err)
+		}
+		prometheus.MustRegister(pingExporter)
+	}
+
+	if *collectorThreads {
+		threadsExporter, err := NewThreadsCollector(*client, solrBaseURL)
+		if err != nil {
+			log.Errorf("Failed to create threads metrics collector: %v", err)
+		}
+		prometheus.MustRegister(threadsExporter)
+	}
+
+	if *collectorLogging {
+		loggingExporter, err := NewLoggingCollector(*client, solrBaseURL)
+		if err != nil {
+			log.Errorf("Failed to create logging metrics collector: %v", err)
+		}
+		prometheus.MustRegister(loggingExporter)
+	}
+
+	if *configFile != "" {
+		config, err := LoadConfig(*configFile)
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		if len(config.Probes) > 0 {
+			probeExporter, err := NewProbeCollector(*client, solrBaseURL, config.Probes)
+			if err != nil {
+				log.Errorf("Failed to create probe metrics collector: %v", err)
+			}
+			prometheus.MustRegister(probeExporter)
+		}
+
+		if len(config.Queries) > 0 {
+			queryExporter, err := NewQueryCollector(*client, solrBaseURL, config.Queries)
+			if err != nil {
+				log.Errorf("Failed to create query metrics collector: %v", err)
+			}
+			prometheus.MustRegister(queryExporter)
+		}
+
+		if len(config.Freshness) > 0 {
+			freshnessExporter, err := NewFreshnessCollector(*client, solrBaseURL, config.Freshness)
+			if err != nil {
+				log.Errorf("Failed to create freshness metrics collector: %v", err)
+			}
+			prometheus.MustRegister(freshnessExporter)
+		}
+
+		if config.Canary != nil {
+			canaryExporter, err := NewCanaryCollector(*client, solrBaseURL, *config.Canary)
+			if err != nil {
+				log.Errorf("Failed to create canary metrics collector: %v", err)
+			}
+			prometheus.MustRegister(canaryExporter)
+			go canaryExporter.Run()
+		}
+	}
+
 	if *solrPidFile != "" {
 		procExporter := prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{
 			PidFn: func() (int, error) {
@@ -92,6 +262,39 @@ func main() {
 		prometheus.MustRegister(procExporter)
 	}
 
+	if *solrLogFile != "" {
+		logFileExporter, err := NewLogFileCollector(*solrLogFile)
+		if err != nil {
+			log.Errorf("Failed to create log file metrics collector: %v", err)
+		}
+		prometheus.MustRegister(logFileExporter)
+		go logFileExporter.Run()
+	}
+
+	if *solrRequestLog != "" {
+		clientNetworks := []string{}
+		for _, network := range strings.Split(*solrRequestLogNetworks, ",") {
+			if network = strings.TrimSpace(network); network != "" {
+				clientNetworks = append(clientNetworks, network)
+			}
+		}
+		requestLogExporter, err := NewRequestLogCollector(*client, solrBaseURL, *solrRequestLog, *solrContextPath, clientNetworks)
+		if err != nil {
+			log.Fatalf("Failed to create request log metrics collector: %v", err)
+		}
+		prometheus.MustRegister(requestLogExporter)
+		go requestLogExporter.Run()
+	}
+
+	if *solrGCLog != "" {
+		gcLogExporter, err := NewGCLogCollector(*solrGCLog)
+		if err != nil {
+			log.Errorf("Failed to create GC log metrics collector: %v", err)
+		}
+		prometheus.MustRegister(gcLogExporter)
+		go gcLogExporter.Run()
+	}
+
 	log.Infoln("Listening on", *listenAddress)
 	http.Handle(*metricsPath, prometheus.Handler())
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
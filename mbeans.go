@@ -59,6 +59,10 @@ func processMbeans(e *Exporter, coreName string, data io.Reader) []error {
 			continue
 		}
 
+		if isDataImportHandler(metrics.Class) {
+			e.dataImportHandlers[coreName] = append(e.dataImportHandlers[coreName], name)
+		}
+
 		var FiveminRateRequestsPerSecond, One5minRateRequestsPerSecond float64
 		if metrics.Stats.One5minRateReqsPerSecond == nil && metrics.Stats.FiveMinRateReqsPerSecond == nil {
 			FiveminRateRequestsPerSecond = float64(metrics.Stats.FiveminRateRequestsPerSecond)
@@ -78,6 +82,7 @@ func processMbeans(e *Exporter, coreName string, data io.Reader) []error {
 		e.gaugeQuery["avg_time_per_request"].WithLabelValues(coreName, name, metrics.Class).Set(float64(metrics.Stats.AvgTimePerRequest))
 		e.gaugeQuery["errors"].WithLabelValues(coreName, name, metrics.Class).Set(float64(metrics.Stats.Errors))
 		e.gaugeQuery["handler_start"].WithLabelValues(coreName, name, metrics.Class).Set(float64(metrics.Stats.HandlerStart))
+		e.gaugeQuery["handler_start_time_seconds"].WithLabelValues(coreName, name, metrics.Class).Set(float64(metrics.Stats.HandlerStart) / 1000)
 		e.gaugeQuery["median_request_time"].WithLabelValues(coreName, name, metrics.Class).Set(float64(metrics.Stats.MedianRequestTime))
 		e.gaugeQuery["requests"].WithLabelValues(coreName, name, metrics.Class).Set(float64(metrics.Stats.Requests))
 		e.gaugeQuery["timeouts"].WithLabelValues(coreName, name, metrics.Class).Set(float64(metrics.Stats.Timeouts))
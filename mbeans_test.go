@@ -53,7 +53,7 @@ func Test_processMbeans(t *testing.T) {
 		want []error
 	}
 	hc := http.Client{}
-	exporter := NewExporter("", time.Second, "", hc)
+	exporter := NewExporter("", time.Second, "", hc, time.Local)
 	tests := []test{}
 	mbeans, err := loadAllMbeans()
 	if err != nil {
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/model"
+)
+
+var (
+	// Keys from the mntr output of zookeeper 3.4 with their help, the others are
+	// exported as gauges named after the key.
+	mntrGauges = map[string]string{
+		"zk_avg_latency":                "Average request latency in milliseconds.",
+		"zk_max_latency":                "Maximum request latency in milliseconds.",
+		"zk_min_latency":                "Minimum request latency in milliseconds.",
+		"zk_num_alive_connections":      "Number of alive connections.",
+		"zk_outstanding_requests":       "Number of queued requests.",
+		"zk_znode_count":                "Number of znodes.",
+		"zk_watch_count":                "Number of watches.",
+		"zk_ephemerals_count":           "Number of ephemeral znodes.",
+		"zk_approximate_data_size":      "Approximate size of the data in bytes.",
+		"zk_open_file_descriptor_count": "Number of open file descriptors.",
+		"zk_max_file_descriptor_count":  "Maximum number of file descriptors.",
+		"zk_followers":                  "Number of followers, only reported by the leader.",
+		"zk_synced_followers":           "Number of synced followers, only reported by the leader.",
+		"zk_pending_syncs":              "Number of pending syncs, only reported by the leader.",
+	}
+	mntrCounters = map[string]string{
+		"zk_packets_received":             "Number of packets received.",
+		"zk_packets_sent":                 "Number of packets sent.",
+		"zk_fsync_threshold_exceed_count": "Number of fsyncs slower than the fsync warning threshold.",
+	}
+	// Lines of the srvr output mapped to their mntr equivalent.
+	srvrKeys = map[string]string{
+		"Zookeeper version": "zk_version",
+		"Received":          "zk_packets_received",
+		"Sent":              "zk_packets_sent",
+		"Connections":       "zk_num_alive_connections",
+		"Outstanding":       "zk_outstanding_requests",
+		"Mode":              "zk_server_state",
+		"Node count":        "zk_znode_count",
+	}
+)
+
+// ZookeeperMntrCollector collects zookeeper metrics by talking directly to
+// each server with the four letter word commands.
+type ZookeeperMntrCollector struct {
+	up          *prometheus.Desc
+	ruok        *prometheus.Desc
+	state       *prometheus.Desc
+	versionInfo *prometheus.Desc
+	metrics     map[string]mntrMetric
+
+	servers []string
+	timeout time.Duration
+}
+
+// mntrMetric is the description of a known mntr key.
+type mntrMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+}
+
+// mntrMetricName returns the metric name of a mntr key, counters get a _total
+// suffix in place of the _count one.
+func mntrMetricName(key string, valueType prometheus.ValueType) string {
+	name := strings.TrimPrefix(key, "zk_")
+	if valueType == prometheus.CounterValue {
+		name = strings.TrimSuffix(name, "_count") + "_total"
+	}
+	return prometheus.BuildFQName(namespace, "zookeeper", name)
+}
+
+// NewZookeeperMntrCollector returns a new Collector exposing statistics of the given zookeeper servers.
+func NewZookeeperMntrCollector(servers []string, timeout time.Duration) (*ZookeeperMntrCollector, error) {
+	metrics := map[string]mntrMetric{}
+	for key, help := range mntrGauges {
+		desc := prometheus.NewDesc(mntrMetricName(key, prometheus.GaugeValue), help, []string{"server"}, nil)
+		metrics[key] = mntrMetric{desc, prometheus.GaugeValue}
+	}
+	for key, help := range mntrCounters {
+		desc := prometheus.NewDesc(mntrMetricName(key, prometheus.CounterValue), help, []string{"server"}, nil)
+		metrics[key] = mntrMetric{desc, prometheus.CounterValue}
+	}
+
+	return &ZookeeperMntrCollector{
+		up: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "up"),
+			"Whether the zookeeper server answered the mntr or srvr command.",
+			[]string{"server"},
+			nil,
+		),
+		ruok: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "ruok"),
+			"Whether the zookeeper server answered imok to ruok.",
+			[]string{"server"},
+			nil,
+		),
+		state: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "state"),
+			"Zookeeper server state (leader, follower, observer or standalone), value is always 1.",
+			[]string{"server", "state"},
+			nil,
+		),
+		versionInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "version_info"),
+			"Zookeeper server version, value is always 1.",
+			[]string{"server", "version"},
+			nil,
+		),
+		metrics: metrics,
+		servers: servers,
+		timeout: timeout,
+	}, nil
+}
+
+// parseZookeeperServers splits a zookeeper connection string into its servers,
+// dropping the chroot if any.
+func parseZookeeperServers(zkHost string) []string {
+	if i := strings.Index(zkHost, "/"); i >= 0 {
+		zkHost = zkHost[:i]
+	}
+	servers := []string{}
+	for _, server := range strings.Split(zkHost, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if !strings.Contains(server, ":") {
+			server = server + ":2181"
+		}
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+// sendFourLetterWord sends cmd to the zookeeper server and returns its answer.
+func sendFourLetterWord(server string, cmd string, timeout time.Duration) (string, error) {
+	conn, err := net.DialTimeout("tcp", server, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write([]byte(cmd)); err != nil {
+		return "", err
+	}
+	answer, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return "", err
+	}
+	return string(answer), nil
+}
+
+// parseMntr parses the tab separated key/value lines of the mntr command.
+func parseMntr(answer string) map[string]string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(answer))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		values[strings.TrimSpace(fields[0])] = strings.TrimSpace(fields[1])
+	}
+	return values
+}
+
+// parseSrvr parses the output of the srvr command into mntr keys.
+func parseSrvr(answer string) map[string]string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(answer))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		if key == "Latency min/avg/max" {
+			latencies := strings.Split(value, "/")
+			if len(latencies) == 3 {
+				values["zk_min_latency"] = latencies[0]
+				values["zk_avg_latency"] = latencies[1]
+				values["zk_max_latency"] = latencies[2]
+			}
+			continue
+		}
+		if mntrKey, ok := srvrKeys[key]; ok {
+			values[mntrKey] = value
+		}
+	}
+	return values
+}
+
+// probe fetches the statistics of a zookeeper server, falling back to srvr when
+// mntr is not whitelisted.
+func (c *ZookeeperMntrCollector) probe(server string) (map[string]string, error) {
+	answer, err := sendFourLetterWord(server, "mntr", c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	if values := parseMntr(answer); len(values) > 0 {
+		return values, nil
+	}
+
+	answer, err = sendFourLetterWord(server, "srvr", c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	values := parseSrvr(answer)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("Unexpected answer to mntr and srvr: %s", strings.TrimSpace(answer))
+	}
+	return values, nil
+}
+
+// Update exposes zookeeper metrics for every configured server.
+func (c *ZookeeperMntrCollector) Update(ch chan<- prometheus.Metric) error {
+	for _, server := range c.servers {
+		answer, err := sendFourLetterWord(server, "ruok", c.timeout)
+		ch <- prometheus.MustNewConstMetric(c.ruok, prometheus.GaugeValue, boolToFloat64(err == nil && answer == "imok"), server)
+
+		values, err := c.probe(server)
+		if err != nil {
+			log.Errorf("Failed to probe zookeeper server %s: %v", server, err)
+			ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0, server)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1, server)
+
+		for key, value := range values {
+			switch key {
+			case "zk_server_state":
+				ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, 1, server, value)
+				continue
+			case "zk_version":
+				ch <- prometheus.MustNewConstMetric(c.versionInfo, prometheus.GaugeValue, 1, server, value)
+				continue
+			}
+
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			if metric, ok := c.metrics[key]; ok {
+				ch <- prometheus.MustNewConstMetric(metric.desc, metric.valueType, f, server)
+				continue
+			}
+
+			// Keys added by later zookeeper versions
+			name := mntrMetricName(key, prometheus.GaugeValue)
+			if !model.IsValidMetricName(model.LabelValue(name)) {
+				continue
+			}
+			desc := prometheus.NewDesc(
+				name,
+				fmt.Sprintf("Zookeeper %s as reported by mntr.", key),
+				[]string{"server"},
+				nil,
+			)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, f, server)
+		}
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *ZookeeperMntrCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect zookeeper mntr metrics: %v", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface. Only the known mntr
+// keys are described, the ones added by later zookeeper versions are not.
+func (c *ZookeeperMntrCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.ruok
+	ch <- c.state
+	ch <- c.versionInfo
+	for _, metric := range c.metrics {
+		ch <- metric.desc
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// startFakeZookeeper starts a TCP server answering the four letter words with
+// the given answers, like zookeeper it closes the connection after answering.
+func startFakeZookeeper(t *testing.T, answers map[string]string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("starting fake zookeeper: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			cmd := make([]byte, 4)
+			if _, err := conn.Read(cmd); err == nil {
+				conn.Write([]byte(answers[string(cmd)]))
+			}
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func collectMetrics(c prometheus.Collector) map[string]*dto.Metric {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	metrics := map[string]*dto.Metric{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		metric.Write(m)
+		labels := []string{}
+		for _, label := range m.GetLabel() {
+			labels = append(labels, label.GetName()+"="+label.GetValue())
+		}
+		name := metric.Desc().String()
+		name = name[strings.Index(name, "\"")+1:]
+		name = name[:strings.Index(name, "\"")]
+		metrics[name+"{"+strings.Join(labels, ",")+"}"] = m
+	}
+	return metrics
+}
+
+func TestZookeeperMntrCollector(t *testing.T) {
+	mntrServer := startFakeZookeeper(t, map[string]string{
+		"ruok": "imok",
+		"mntr": "zk_version\t3.4.13-2d71af4dbe22557fda74f9a9b4309b15a7487f03, built on 06/29/2018 04:05 GMT\n" +
+			"zk_avg_latency\t2\n" +
+			"zk_max_latency\t18\n" +
+			"zk_packets_received\t1234\n" +
+			"zk_fsync_threshold_exceed_count\t3\n" +
+			"zk_last_proposal_size\t92\n" +
+			"zk_server_state\tleader\n" +
+			"zk_znode_count\t54\n",
+	})
+	srvrServer := startFakeZookeeper(t, map[string]string{
+		"ruok": "imok",
+		"mntr": "mntr is not executed because it is not in the whitelist.\n",
+		"srvr": "Zookeeper version: 3.4.13-2d71af4dbe22557fda74f9a9b4309b15a7487f03, built on 06/29/2018 04:05 GMT\n" +
+			"Latency min/avg/max: 0/3/21\n" +
+			"Received: 42\n" +
+			"Sent: 41\n" +
+			"Connections: 2\n" +
+			"Outstanding: 0\n" +
+			"Zxid: 0x1a\n" +
+			"Mode: follower\n" +
+			"Node count: 54\n",
+	})
+
+	collector, _ := NewZookeeperMntrCollector([]string{mntrServer, srvrServer, "127.0.0.1:1"}, time.Second)
+	metrics := collectMetrics(collector)
+
+	tests := []struct {
+		name string
+		want float64
+	}{
+		{"solr_zookeeper_up{server=" + mntrServer + "}", 1},
+		{"solr_zookeeper_ruok{server=" + mntrServer + "}", 1},
+		{"solr_zookeeper_avg_latency{server=" + mntrServer + "}", 2},
+		{"solr_zookeeper_max_latency{server=" + mntrServer + "}", 18},
+		{"solr_zookeeper_packets_received_total{server=" + mntrServer + "}", 1234},
+		{"solr_zookeeper_fsync_threshold_exceed_total{server=" + mntrServer + "}", 3},
+		{"solr_zookeeper_last_proposal_size{server=" + mntrServer + "}", 92},
+		{"solr_zookeeper_state{server=" + mntrServer + ",state=leader}", 1},
+		{"solr_zookeeper_up{server=" + srvrServer + "}", 1},
+		{"solr_zookeeper_avg_latency{server=" + srvrServer + "}", 3},
+		{"solr_zookeeper_num_alive_connections{server=" + srvrServer + "}", 2},
+		{"solr_zookeeper_packets_sent_total{server=" + srvrServer + "}", 41},
+		{"solr_zookeeper_state{server=" + srvrServer + ",state=follower}", 1},
+		{"solr_zookeeper_up{server=127.0.0.1:1}", 0},
+		{"solr_zookeeper_ruok{server=127.0.0.1:1}", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := metrics[tt.name]
+			if !ok {
+				t.Fatalf("metric not collected")
+			}
+			var got float64
+			if m.Counter != nil {
+				got = m.GetCounter().GetValue()
+			} else {
+				got = m.GetGauge().GetValue()
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZookeeperMntrCollectorDescribe(t *testing.T) {
+	collector, _ := NewZookeeperMntrCollector([]string{"127.0.0.1:1"}, time.Second)
+	ch := make(chan *prometheus.Desc, 100)
+	collector.Describe(ch)
+	close(ch)
+
+	described := map[string]bool{}
+	for desc := range ch {
+		described[desc.String()] = true
+	}
+	if got, want := len(described), 4+len(mntrGauges)+len(mntrCounters); got != want {
+		t.Errorf("described %d metrics, want %d", got, want)
+	}
+}
+
+func Test_parseZookeeperServers(t *testing.T) {
+	got := parseZookeeperServers("zk1:2181, zk2:2182,zk3/solr")
+	want := []string{"zk1:2181", "zk2:2182", "zk3:2181"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseZookeeperServers() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var overseerStatusPath = "/admin/collections?action=OVERSEERSTATUS&wt=json"
+
+// OverseerCollector collects overseer metrics from solr
+type OverseerCollector struct {
+	leaderInfo            *prometheus.Desc
+	queueSize             *prometheus.Desc
+	operationRequests     *prometheus.Desc
+	operationErrors       *prometheus.Desc
+	operationAvgTime      *prometheus.Desc
+	operationRequestTimes *prometheus.Desc
+
+	client            http.Client
+	overseerStatusURL string
+}
+
+// NewOverseerCollector returns a new Collector exposing solr overseer statistics.
+func NewOverseerCollector(client http.Client, solrBaseURL string) (*OverseerCollector, error) {
+	overseerStatusURL := fmt.Sprintf("%s%s", solrBaseURL, overseerStatusPath)
+	return &OverseerCollector{
+		leaderInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "overseer", "leader_info"),
+			"Current overseer leader, value is always 1.",
+			[]string{"leader"},
+			nil,
+		),
+		queueSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "overseer", "queue_size"),
+			"Number of pending items in the overseer queues.",
+			[]string{"queue"},
+			nil,
+		),
+		operationRequests: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "overseer", "operation_requests_total"),
+			"Number of requests processed by the overseer per operation.",
+			[]string{"type", "operation"},
+			nil,
+		),
+		operationErrors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "overseer", "operation_errors_total"),
+			"Number of failed requests processed by the overseer per operation.",
+			[]string{"type", "operation"},
+			nil,
+		),
+		operationAvgTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "overseer", "operation_avg_request_time"),
+			"Average overseer operation request time in milliseconds.",
+			[]string{"type", "operation"},
+			nil,
+		),
+		operationRequestTimes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "overseer", "operation_request_time"),
+			"Overseer operation request time percentiles in milliseconds.",
+			[]string{"type", "operation", "quantile"},
+			nil,
+		),
+		client:            client,
+		overseerStatusURL: overseerStatusURL,
+	}, nil
+}
+
+// parseOverseerOperations decodes the flattened name/stats list used by the
+// overseer status to describe its operations.
+func parseOverseerOperations(data []json.RawMessage) (map[string]OverseerOperation, error) {
+	operations := map[string]OverseerOperation{}
+	for i := 0; i+1 < len(data); i += 2 {
+		var name string
+		if err := json.Unmarshal(data[i], &name); err != nil {
+			return nil, fmt.Errorf("Failed to unmarshal overseer operation name: %v", err)
+		}
+		var operation OverseerOperation
+		if err := json.Unmarshal(data[i+1], &operation); err != nil {
+			return nil, fmt.Errorf("Failed to unmarshal overseer operation %s: %v", name, err)
+		}
+		operations[name] = operation
+	}
+	return operations, nil
+}
+
+// Update exposes overseer related metrics from solr.
+func (c *OverseerCollector) Update(ch chan<- prometheus.Metric) error {
+	body, err := getBody(c.client, c.overseerStatusURL)
+	if err != nil {
+		// Solr answers with a bad request when it is not running in SolrCloud mode
+		if hasStatusCode(err, http.StatusBadRequest) {
+			log.Debugf("Solr is not running in SolrCloud mode, skipping overseer status")
+			return nil
+		}
+		return fmt.Errorf("Error while querying Solr for overseer status: %v", err)
+	}
+
+	body = bytes.Replace(body, []byte(":\"NaN\""), []byte(":0.0"), -1)
+	overseerStatus := &OverseerStatus{}
+	if err := json.Unmarshal(body, overseerStatus); err != nil {
+		return fmt.Errorf("Failed to unmarshal solr overseer status JSON into struct: %v", err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.leaderInfo, prometheus.GaugeValue, 1, overseerStatus.Leader)
+	ch <- prometheus.MustNewConstMetric(c.queueSize, prometheus.GaugeValue, float64(overseerStatus.OverseerQueueSize), "overseer")
+	ch <- prometheus.MustNewConstMetric(c.queueSize, prometheus.GaugeValue, float64(overseerStatus.OverseerWorkQueueSize), "work")
+	ch <- prometheus.MustNewConstMetric(c.queueSize, prometheus.GaugeValue, float64(overseerStatus.OverseerCollectionQueueSize), "collection")
+
+	operationTypes := map[string][]json.RawMessage{
+		"overseer":                overseerStatus.OverseerOperations,
+		"collection":              overseerStatus.CollectionOperations,
+		"overseer_queue":          overseerStatus.OverseerQueue,
+		"overseer_internal_queue": overseerStatus.OverseerInternalQueue,
+		"collection_queue":        overseerStatus.CollectionQueue,
+	}
+	for operationType, data := range operationTypes {
+		operations, err := parseOverseerOperations(data)
+		if err != nil {
+			return err
+		}
+		for name, operation := range operations {
+			ch <- prometheus.MustNewConstMetric(c.operationRequests, prometheus.CounterValue, float64(operation.Requests), operationType, name)
+			ch <- prometheus.MustNewConstMetric(c.operationErrors, prometheus.CounterValue, float64(operation.Errors), operationType, name)
+			ch <- prometheus.MustNewConstMetric(c.operationAvgTime, prometheus.GaugeValue, operation.AvgTimePerRequest, operationType, name)
+			ch <- prometheus.MustNewConstMetric(c.operationRequestTimes, prometheus.GaugeValue, operation.MedianRequestTime, operationType, name, "0.5")
+			ch <- prometheus.MustNewConstMetric(c.operationRequestTimes, prometheus.GaugeValue, operation.Seven5thPcRequestTime, operationType, name, "0.75")
+			ch <- prometheus.MustNewConstMetric(c.operationRequestTimes, prometheus.GaugeValue, operation.Nine5thPcRequestTime, operationType, name, "0.95")
+			ch <- prometheus.MustNewConstMetric(c.operationRequestTimes, prometheus.GaugeValue, operation.Nine9thPcRequestTime, operationType, name, "0.99")
+			ch <- prometheus.MustNewConstMetric(c.operationRequestTimes, prometheus.GaugeValue, operation.Nine99thPcRequestTime, operationType, name, "0.999")
+		}
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *OverseerCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect overseer metrics: %v", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *OverseerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.leaderInfo
+	ch <- c.queueSize
+	ch <- c.operationRequests
+	ch <- c.operationErrors
+	ch <- c.operationAvgTime
+	ch <- c.operationRequestTimes
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Response of the Solr 7 OVERSEERSTATUS action, the statistics of operations
+// without timings are NaN.
+const overseerStatusResponse = `{
+  "responseHeader":{
+    "status":0,
+    "QTime":7},
+  "leader":"10.0.0.1:8983_solr",
+  "overseer_queue_size":3,
+  "overseer_work_queue_size":0,
+  "overseer_collection_queue_size":2,
+  "overseer_operations":[
+    "am_i_leader",{
+      "requests":1208,
+      "errors":0,
+      "avgRequestsPerSecond":0.0332,
+      "5minRateRequestsPerSecond":0.0328,
+      "15minRateRequestsPerSecond":0.0331,
+      "avgTimePerRequest":0.4121,
+      "medianRequestTime":0.3891,
+      "75thPcRequestTime":0.4512,
+      "95thPcRequestTime":0.6123,
+      "99thPcRequestTime":1.2034,
+      "999thPcRequestTime":1.2034},
+    "state",{
+      "requests":45,
+      "errors":0,
+      "avgRequestsPerSecond":0.0012,
+      "5minRateRequestsPerSecond":0.0,
+      "15minRateRequestsPerSecond":0.0,
+      "avgTimePerRequest":"NaN",
+      "medianRequestTime":"NaN",
+      "75thPcRequestTime":"NaN",
+      "95thPcRequestTime":"NaN",
+      "99thPcRequestTime":"NaN",
+      "999thPcRequestTime":"NaN"}],
+  "collection_operations":[
+    "overseerstatus",{
+      "requests":12,
+      "errors":0,
+      "avgRequestsPerSecond":0.0003,
+      "5minRateRequestsPerSecond":0.0,
+      "15minRateRequestsPerSecond":0.0,
+      "avgTimePerRequest":5.1234,
+      "medianRequestTime":4.9876,
+      "75thPcRequestTime":5.5,
+      "95thPcRequestTime":7.25,
+      "99thPcRequestTime":7.25,
+      "999thPcRequestTime":7.25},
+    "create",{
+      "requests":2,
+      "errors":1,
+      "recent_failures":[{
+          "request":{
+            "operation":"create",
+            "name":"broken"},
+          "response":[
+            "Operation create caused exception:","org.apache.solr.common.SolrException: Could not find configName for collection broken"]}],
+      "avgRequestsPerSecond":0.0001,
+      "5minRateRequestsPerSecond":0.0,
+      "15minRateRequestsPerSecond":0.0,
+      "avgTimePerRequest":1502.5,
+      "medianRequestTime":1502.5,
+      "75thPcRequestTime":2001.0,
+      "95thPcRequestTime":2001.0,
+      "99thPcRequestTime":2001.0,
+      "999thPcRequestTime":2001.0}],
+  "overseer_queue":[
+    "state",{
+      "avgRequestsPerSecond":0.0012,
+      "5minRateRequestsPerSecond":0.0,
+      "15minRateRequestsPerSecond":0.0,
+      "avgTimePerRequest":0.2,
+      "medianRequestTime":0.2,
+      "75thPcRequestTime":0.2,
+      "95thPcRequestTime":0.2,
+      "99thPcRequestTime":0.2,
+      "999thPcRequestTime":0.2}],
+  "overseer_internal_queue":[],
+  "collection_queue":[]}`
+
+func TestParseOverseerOperations(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		data       string
+		operations map[string]OverseerOperation
+		err        bool
+	}{
+		{
+			name:       "empty",
+			data:       `[]`,
+			operations: map[string]OverseerOperation{},
+		},
+		{
+			name: "operations",
+			data: `["am_i_leader", {"requests": 10, "errors": 1, "avgTimePerRequest": 0.5, "999thPcRequestTime": 2.5}, "state", {"requests": 3}]`,
+			operations: map[string]OverseerOperation{
+				"am_i_leader": {Requests: 10, Errors: 1, AvgTimePerRequest: 0.5, Nine99thPcRequestTime: 2.5},
+				"state":       {Requests: 3},
+			},
+		},
+		{
+			name:       "name without statistics",
+			data:       `["am_i_leader", {"requests": 10}, "state"]`,
+			operations: map[string]OverseerOperation{"am_i_leader": {Requests: 10}},
+		},
+		{
+			name: "statistics instead of a name",
+			data: `[{"requests": 10}, {"requests": 10}]`,
+			err:  true,
+		},
+		{
+			name: "NaN timings",
+			data: `["state", {"requests": 10, "avgTimePerRequest": "NaN"}]`,
+			err:  true,
+		},
+	} {
+		data := []json.RawMessage{}
+		if err := json.Unmarshal([]byte(test.data), &data); err != nil {
+			t.Fatalf("%s: invalid test data: %v", test.name, err)
+		}
+		operations, err := parseOverseerOperations(data)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if len(operations) != len(test.operations) {
+			t.Errorf("%s: got %d operations, want %d: %v", test.name, len(operations), len(test.operations), operations)
+		}
+		for name, want := range test.operations {
+			if got := operations[name]; got != want {
+				t.Errorf("%s: operation %s = %+v, want %+v", test.name, name, got, want)
+			}
+		}
+	}
+}
+
+func TestOverseerCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, overseerStatusResponse)
+	}))
+	defer server.Close()
+
+	collector, err := NewOverseerCollector(*http.DefaultClient, server.URL+"/solr")
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	metrics := collectMetrics(collector)
+	for metric, want := range map[string]float64{
+		"solr_overseer_leader_info{leader=10.0.0.1:8983_solr}":                                     1,
+		"solr_overseer_queue_size{queue=overseer}":                                                 3,
+		"solr_overseer_queue_size{queue=collection}":                                               2,
+		"solr_overseer_operation_avg_request_time{operation=am_i_leader,type=overseer}":            0.4121,
+		"solr_overseer_operation_request_time{operation=am_i_leader,quantile=0.99,type=overseer}":  1.2034,
+		"solr_overseer_operation_avg_request_time{operation=state,type=overseer}":                  0,
+		"solr_overseer_operation_request_time{operation=state,quantile=0.5,type=overseer}":         0,
+		"solr_overseer_operation_avg_request_time{operation=create,type=collection}":               1502.5,
+		"solr_overseer_operation_request_time{operation=state,quantile=0.999,type=overseer_queue}": 0.2,
+	} {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if got := m.GetGauge().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	for metric, want := range map[string]float64{
+		"solr_overseer_operation_requests_total{operation=am_i_leader,type=overseer}": 1208,
+		"solr_overseer_operation_requests_total{operation=create,type=collection}":    2,
+		"solr_overseer_operation_errors_total{operation=create,type=collection}":      1,
+		"solr_overseer_operation_requests_total{operation=state,type=overseer_queue}": 0,
+	} {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if got := m.GetCounter().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+}
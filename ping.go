@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var (
+	pingPath       = "/admin/ping?wt=json"
+	pingStatusPath = "/admin/ping?action=status&wt=json"
+)
+
+// PingCollector checks every core answers to the ping request handler
+type PingCollector struct {
+	up       *prometheus.Desc
+	enabled  *prometheus.Desc
+	duration *prometheus.HistogramVec
+	failures *prometheus.CounterVec
+
+	client        http.Client
+	adminCoresURL string
+	pingURL       string
+	pingStatusURL string
+
+	mutex sync.Mutex
+}
+
+// NewPingCollector returns a new Collector exposing solr cores health.
+func NewPingCollector(client http.Client, solrBaseURL string) (*PingCollector, error) {
+	adminCoresURL := fmt.Sprintf("%s%s", solrBaseURL, adminCoresPath)
+	pingURL := fmt.Sprintf("%s/%s%s", solrBaseURL, "%s", pingPath)
+	pingStatusURL := fmt.Sprintf("%s/%s%s", solrBaseURL, "%s", pingStatusPath)
+	return &PingCollector{
+		up: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "core", "ping_up"),
+			"Whether the core answered OK to the ping request.",
+			[]string{"core"},
+			nil,
+		),
+		enabled: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "core", "ping_enabled"),
+			"Whether the core is enabled, 0 when disabled through the healthcheck file.",
+			[]string{"core"},
+			nil,
+		),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "core",
+			Name:      "ping_duration_seconds",
+			Help:      "Duration of the ping requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"core"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "core",
+			Name:      "ping_failures_total",
+			Help:      "Number of failed ping requests by reason.",
+		}, []string{"core", "reason"}),
+		client:        client,
+		adminCoresURL: adminCoresURL,
+		pingURL:       pingURL,
+		pingStatusURL: pingStatusURL,
+	}, nil
+}
+
+// isDisabled reports whether the core was disabled through the healthcheck file.
+func (c *PingCollector) isDisabled(coreName string) bool {
+	pingStatus := &PingStatus{}
+	if err := getJSON(c.client, fmt.Sprintf(c.pingStatusURL, coreName), pingStatus); err != nil {
+		return false
+	}
+	return pingStatus.Status == "disabled"
+}
+
+// ping sends a ping request to the core, returning the failure reason if any.
+func (c *PingCollector) ping(coreName string) string {
+	resp, err := c.client.Get(fmt.Sprintf(c.pingURL, coreName))
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return "timeout"
+		}
+		return "error"
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "http_status"
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "error"
+	}
+	pingStatus := &PingStatus{}
+	if err := json.Unmarshal(body, pingStatus); err != nil || pingStatus.Status != "OK" {
+		return "status"
+	}
+	return ""
+}
+
+// Update exposes ping related metrics from solr.
+func (c *PingCollector) Update(ch chan<- prometheus.Metric) error {
+	adminCoresStatus, err := getAdminCoresStatus(c.client, c.adminCoresURL)
+	if err != nil {
+		return err
+	}
+
+	for _, coreName := range getCoresFromStatus(adminCoresStatus) {
+		if isExcludedCore(coreName) {
+			continue
+		}
+
+		start := time.Now()
+		reason := c.ping(coreName)
+		duration := time.Since(start)
+
+		// A disabled core answers with service unavailable, which is expected.
+		if reason == "http_status" && c.isDisabled(coreName) {
+			ch <- prometheus.MustNewConstMetric(c.enabled, prometheus.GaugeValue, 0, coreName)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.enabled, prometheus.GaugeValue, 1, coreName)
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, boolToFloat64(reason == ""), coreName)
+		c.duration.WithLabelValues(coreName).Observe(duration.Seconds())
+		if reason != "" {
+			c.failures.WithLabelValues(coreName, reason).Inc()
+		}
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *PingCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects.
+	defer c.mutex.Unlock()
+
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect ping metrics: %v", err)
+	}
+	c.duration.Collect(ch)
+	c.failures.Collect(ch)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *PingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.enabled
+	c.duration.Describe(ch)
+	c.failures.Describe(ch)
+}
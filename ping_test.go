@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPingCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("action") == "status"
+		switch r.URL.Path {
+		case "/solr/admin/cores":
+			fmt.Fprint(w, `{"status": {"ok": {}, "disabled": {}, "unavailable": {}, "failing": {}, "slow": {}}}`)
+		case "/solr/ok/admin/ping":
+			fmt.Fprint(w, `{"responseHeader": {"status": 0, "QTime": 1}, "status": "OK"}`)
+		case "/solr/disabled/admin/ping":
+			if status {
+				fmt.Fprint(w, `{"responseHeader": {"status": 0, "QTime": 0}, "status": "disabled"}`)
+				return
+			}
+			http.Error(w, `{"error": {"msg": "Service disabled", "code": 503}}`, http.StatusServiceUnavailable)
+		case "/solr/unavailable/admin/ping":
+			if status {
+				fmt.Fprint(w, `{"responseHeader": {"status": 0, "QTime": 0}, "status": "enabled"}`)
+				return
+			}
+			http.Error(w, `{"error": {"msg": "Ping query caused exception", "code": 500}}`, http.StatusInternalServerError)
+		case "/solr/failing/admin/ping":
+			fmt.Fprint(w, `{"responseHeader": {"status": 0, "QTime": 1}}`)
+		case "/solr/slow/admin/ping":
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `{"responseHeader": {"status": 0, "QTime": 200}, "status": "OK"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	collector, err := NewPingCollector(http.Client{Timeout: 50 * time.Millisecond}, server.URL+"/solr")
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	metrics := collectMetrics(collector)
+	for metric, want := range map[string]float64{
+		"solr_core_ping_enabled{core=ok}":          1,
+		"solr_core_ping_up{core=ok}":               1,
+		"solr_core_ping_enabled{core=disabled}":    0,
+		"solr_core_ping_up{core=unavailable}":      0,
+		"solr_core_ping_up{core=failing}":          0,
+		"solr_core_ping_up{core=slow}":             0,
+		"solr_core_ping_enabled{core=unavailable}": 1,
+	} {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if got := m.GetGauge().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	for metric, want := range map[string]float64{
+		"solr_core_ping_failures_total{core=unavailable,reason=http_status}": 1,
+		"solr_core_ping_failures_total{core=failing,reason=status}":          1,
+		"solr_core_ping_failures_total{core=slow,reason=timeout}":            1,
+	} {
+		if got := metrics[metric].GetCounter().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	// A disabled core is neither up nor failing
+	for _, metric := range []string{
+		"solr_core_ping_up{core=disabled}",
+		"solr_core_ping_duration_seconds{core=disabled}",
+		"solr_core_ping_failures_total{core=disabled,reason=http_status}",
+		"solr_core_ping_failures_total{core=ok,reason=status}",
+	} {
+		if _, ok := metrics[metric]; ok {
+			t.Errorf("unexpected metric %s", metric)
+		}
+	}
+	if count := metrics["solr_core_ping_duration_seconds{core=ok}"].GetHistogram().GetSampleCount(); count != 1 {
+		t.Errorf("ok ping duration sample count = %d, want 1", count)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// ProbeCollector sends the configured queries to solr and checks their results.
+// Probes without interval run on every scrape, the others at most once per
+// interval.
+type ProbeCollector struct {
+	duration *prometheus.HistogramVec
+	qtime    *prometheus.HistogramVec
+	runs     *prometheus.CounterVec
+	success  *prometheus.GaugeVec
+	timing   *prometheus.HistogramVec
+
+	client      http.Client
+	solrBaseURL string
+	probes      []ProbeConfig
+
+	mutex   sync.Mutex
+	lastRun map[string]time.Time
+}
+
+// NewProbeCollector returns a new Collector running the given probes.
+func NewProbeCollector(client http.Client, solrBaseURL string, probes []ProbeConfig) (*ProbeCollector, error) {
+	return &ProbeCollector{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "probe",
+			Name:      "duration_seconds",
+			Help:      "Wall clock duration of the probe queries.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"probe"}),
+		qtime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "probe",
+			Name:      "qtime_seconds",
+			Help:      "Query time of the probe queries as reported by solr.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"probe"}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "probe",
+			Name:      "runs_total",
+			Help:      "Number of probe runs by result.",
+		}, []string{"probe", "result"}),
+		success: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "probe",
+			Name:      "success",
+			Help:      "Whether the last run of the probe succeeded.",
+		}, []string{"probe"}),
+		timing: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "probe",
+			Name:      "component_duration_seconds",
+			Help:      "Time spent by the search components of the probes with debug timing, per phase.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"probe", "phase", "component"}),
+		client:      client,
+		solrBaseURL: solrBaseURL,
+		probes:      probes,
+		lastRun:     map[string]time.Time{},
+	}, nil
+}
+
+// queryURL returns the url of a query sent to the handler of the target core or collection.
+func queryURL(solrBaseURL string, target string, handler string, params url.Values) string {
+	params.Set("wt", "json")
+	return fmt.Sprintf("%s/%s%s?%s", solrBaseURL, target, handler, params.Encode())
+}
+
+// checkProbeResponse returns an error when the response breaks one of the probe assertions.
+func checkProbeResponse(probe ProbeConfig, response *QueryResponse) error {
+	if response.ResponseHeader.Status != 0 {
+		return fmt.Errorf("Solr answered with status %d", response.ResponseHeader.Status)
+	}
+	numFound := response.Response.NumFound
+	if probe.MinNumFound != nil && numFound < *probe.MinNumFound {
+		return fmt.Errorf("Found %d documents, expected at least %d", numFound, *probe.MinNumFound)
+	}
+	if probe.MaxNumFound != nil && numFound > *probe.MaxNumFound {
+		return fmt.Errorf("Found %d documents, expected at most %d", numFound, *probe.MaxNumFound)
+	}
+	qtime := time.Duration(response.ResponseHeader.QTime) * time.Millisecond
+	if probe.MaxQTime > 0 && qtime > time.Duration(probe.MaxQTime) {
+		return fmt.Errorf("Query took %v, expected at most %v", qtime, time.Duration(probe.MaxQTime))
+	}
+	return nil
+}
+
+// observeTiming records the time spent by each search component, as reported
+// in milliseconds by debug=timing for the prepare and process phases.
+func (c *ProbeCollector) observeTiming(probe ProbeConfig, timing map[string]json.RawMessage) {
+	for _, phase := range []string{"prepare", "process"} {
+		components := map[string]json.RawMessage{}
+		if err := json.Unmarshal(timing[phase], &components); err != nil {
+			continue
+		}
+		for component, raw := range components {
+			// The phase total is listed along with the components.
+			if component == "time" {
+				continue
+			}
+			componentTiming := &ComponentTiming{}
+			if err := json.Unmarshal(raw, componentTiming); err != nil {
+				continue
+			}
+			c.timing.WithLabelValues(probe.Name, phase, component).Observe(componentTiming.Time / 1000)
+		}
+	}
+}
+
+// run sends the probe query and records its result.
+func (c *ProbeCollector) run(probe ProbeConfig) {
+	params := probe.Params.Values()
+	if probe.DebugTiming {
+		params.Set("debug", "timing")
+	}
+
+	response := &QueryResponse{}
+	start := time.Now()
+	err := getJSON(c.client, queryURL(c.solrBaseURL, probe.Target, probe.Handler, params), response)
+	c.duration.WithLabelValues(probe.Name).Observe(time.Since(start).Seconds())
+	if err == nil {
+		c.qtime.WithLabelValues(probe.Name).Observe(float64(response.ResponseHeader.QTime) / 1000)
+		if probe.DebugTiming {
+			c.observeTiming(probe, response.Debug.Timing)
+		}
+		err = checkProbeResponse(probe, response)
+	}
+
+	if err != nil {
+		log.Warnf("Probe %s failed: %v", probe.Name, err)
+		c.runs.WithLabelValues(probe.Name, "failure").Inc()
+		c.success.WithLabelValues(probe.Name).Set(0)
+		return
+	}
+	c.runs.WithLabelValues(probe.Name, "success").Inc()
+	c.success.WithLabelValues(probe.Name).Set(1)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *ProbeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects.
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for _, probe := range c.probes {
+		if now.Sub(c.lastRun[probe.Name]) < time.Duration(probe.Interval) {
+			continue
+		}
+		c.lastRun[probe.Name] = now
+		c.run(probe)
+	}
+
+	c.duration.Collect(ch)
+	c.qtime.Collect(ch)
+	c.runs.Collect(ch)
+	c.success.Collect(ch)
+	c.timing.Collect(ch)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *ProbeCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.duration.Describe(ch)
+	c.qtime.Describe(ch)
+	c.runs.Describe(ch)
+	c.success.Describe(ch)
+	c.timing.Describe(ch)
+}
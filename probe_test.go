@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := parseConfig([]byte(`{
+		"probes": [
+			{
+				"name": "search",
+				"target": "products",
+				"handler": "query",
+				"params": {"q": "*:*", "fq": ["a:1", "b:2"]},
+				"interval": "30s",
+				"min_num_found": 1,
+				"max_qtime": "200ms"
+			},
+			{"name": "default", "target": "products"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("parsing config: %v", err)
+	}
+
+	search := config.Probes[0]
+	if search.Handler != "/query" {
+		t.Errorf("handler = %q, want /query", search.Handler)
+	}
+	if fq := search.Params.Values()["fq"]; len(fq) != 2 {
+		t.Errorf("fq = %v, want 2 values", fq)
+	}
+	if time.Duration(search.Interval) != 30*time.Second || time.Duration(search.MaxQTime) != 200*time.Millisecond {
+		t.Errorf("interval = %v, max_qtime = %v", search.Interval, search.MaxQTime)
+	}
+	if search.MinNumFound == nil || *search.MinNumFound != 1 || search.MaxNumFound != nil {
+		t.Errorf("unexpected numFound bounds %v %v", search.MinNumFound, search.MaxNumFound)
+	}
+	if config.Probes[1].Handler != "/select" {
+		t.Errorf("default handler = %q, want /select", config.Probes[1].Handler)
+	}
+
+	for _, invalid := range []string{
+		`{"probes": [{"target": "products"}]}`,
+		`{"probes": [{"name": "search"}]}`,
+		`{"probes": [{"name": "search", "target": "a"}, {"name": "search", "target": "b"}]}`,
+		`{"probes": [{"name": "search", "target": "a", "interval": "soon"}]}`,
+	} {
+		if _, err := parseConfig([]byte(invalid)); err == nil {
+			t.Errorf("parseConfig(%s) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestProbeCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/products/select" || r.URL.Query().Get("wt") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("debug") == "timing" {
+			fmt.Fprintf(w, `{"responseHeader": {"status": 0, "QTime": 12}, "response": {"numFound": 5, "docs": []},
+				"debug": {"timing": {
+					"time": 12.0,
+					"prepare": {"time": 1.0, "query": {"time": 1.0}, "facet": {"time": 0.0}},
+					"process": {"time": 11.0, "query": {"time": 4.0}, "facet": {"time": 7.0}}
+				}}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"responseHeader": {"status": 0, "QTime": 12}, "response": {"numFound": 5, "docs": []}}`)
+	}))
+	defer server.Close()
+
+	config, err := parseConfig([]byte(`{
+		"probes": [
+			{"name": "ok", "target": "products", "params": {"q": "*:*"}, "min_num_found": 1},
+			{"name": "too_few", "target": "products", "min_num_found": 10},
+			{"name": "too_slow", "target": "products", "max_qtime": "10ms"},
+			{"name": "missing", "target": "unknown"},
+			{"name": "hourly", "target": "products", "interval": "1h"},
+			{"name": "timed", "target": "products", "debug_timing": true}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("parsing config: %v", err)
+	}
+	collector, err := NewProbeCollector(*http.DefaultClient, server.URL+"/solr", config.Probes)
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	collectMetrics(collector)
+	metrics := collectMetrics(collector)
+
+	for metric, want := range map[string]float64{
+		"solr_probe_success{probe=ok}":                        1,
+		"solr_probe_success{probe=too_few}":                   0,
+		"solr_probe_success{probe=too_slow}":                  0,
+		"solr_probe_success{probe=missing}":                   0,
+		"solr_probe_runs_total{probe=ok,result=success}":      2,
+		"solr_probe_runs_total{probe=too_few,result=failure}": 2,
+		"solr_probe_runs_total{probe=hourly,result=success}":  1,
+	} {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		value := m.GetGauge().GetValue() + m.GetCounter().GetValue()
+		if value != want {
+			t.Errorf("%s = %v, want %v", metric, value, want)
+		}
+	}
+
+	qtime := metrics["solr_probe_qtime_seconds{probe=ok}"].GetHistogram()
+	if qtime.GetSampleCount() != 2 || math.Abs(qtime.GetSampleSum()-0.024) > 1e-9 {
+		t.Errorf("qtime histogram count = %d, sum = %v", qtime.GetSampleCount(), qtime.GetSampleSum())
+	}
+	if _, ok := metrics["solr_probe_qtime_seconds{probe=missing}"]; ok {
+		t.Errorf("qtime observed for a failed request")
+	}
+
+	for metric, want := range map[string]float64{
+		"solr_probe_component_duration_seconds{component=query,phase=prepare,probe=timed}": 0.002,
+		"solr_probe_component_duration_seconds{component=facet,phase=prepare,probe=timed}": 0,
+		"solr_probe_component_duration_seconds{component=query,phase=process,probe=timed}": 0.008,
+		"solr_probe_component_duration_seconds{component=facet,phase=process,probe=timed}": 0.014,
+	} {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if sum := m.GetHistogram().GetSampleSum(); math.Abs(sum-want) > 1e-9 {
+			t.Errorf("%s sum = %v, want %v", metric, sum, want)
+		}
+	}
+	if _, ok := metrics["solr_probe_component_duration_seconds{component=query,phase=process,probe=ok}"]; ok {
+		t.Errorf("component timing observed for a probe without debug timing")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// QueryCollector exports the results of the configured queries: the number of
+// matching documents and the counts of the JSON Facet API buckets. Queries
+// without interval run on every scrape, the others at most once per interval,
+// the last results being exported in between.
+type QueryCollector struct {
+	up         *prometheus.Desc
+	numFound   *prometheus.Desc
+	facetCount *prometheus.Desc
+
+	client      http.Client
+	solrBaseURL string
+	queries     []QueryConfig
+
+	mutex   sync.Mutex
+	lastRun map[string]time.Time
+	metrics map[string][]prometheus.Metric
+}
+
+// NewQueryCollector returns a new Collector exposing the results of the given queries.
+func NewQueryCollector(client http.Client, solrBaseURL string, queries []QueryConfig) (*QueryCollector, error) {
+	return &QueryCollector{
+		up: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "query", "up"),
+			"Whether the last run of the query succeeded.",
+			[]string{"query"},
+			nil,
+		),
+		numFound: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "query", "num_found"),
+			"Number of documents matching the query.",
+			[]string{"query"},
+			nil,
+		),
+		facetCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "query", "facet_count"),
+			"Number of documents in the facet bucket.",
+			[]string{"query", "facet", "bucket"},
+			nil,
+		),
+		client:      client,
+		solrBaseURL: solrBaseURL,
+		queries:     queries,
+		lastRun:     map[string]time.Time{},
+		metrics:     map[string][]prometheus.Metric{},
+	}, nil
+}
+
+// run sends the query and returns the metrics built from its results.
+func (c *QueryCollector) run(query QueryConfig) ([]prometheus.Metric, error) {
+	params := query.Params.Values()
+	// Only counts are exported, there is no need to fetch any document.
+	if _, ok := params["rows"]; !ok {
+		params.Set("rows", "0")
+	}
+
+	response := &QueryResponse{}
+	if err := getJSON(c.client, queryURL(c.solrBaseURL, query.Target, query.Handler, params), response); err != nil {
+		return nil, err
+	}
+
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(c.numFound, prometheus.GaugeValue, float64(response.Response.NumFound), query.Name),
+	}
+	for facetName, facet := range response.Facets {
+		// The facets object also holds the overall count and query facets.
+		facetBuckets := &FacetBuckets{}
+		if err := json.Unmarshal(facet, facetBuckets); err != nil {
+			continue
+		}
+		for _, bucket := range facetBuckets.Buckets {
+			metrics = append(metrics, prometheus.MustNewConstMetric(c.facetCount, prometheus.GaugeValue, float64(bucket.Count), query.Name, facetName, string(bucket.Val)))
+		}
+	}
+	return metrics, nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *QueryCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for _, query := range c.queries {
+		if now.Sub(c.lastRun[query.Name]) >= time.Duration(query.Interval) {
+			c.lastRun[query.Name] = now
+			metrics, err := c.run(query)
+			if err != nil {
+				log.Errorf("Failed to collect metrics of query %s: %v", query.Name, err)
+			}
+			c.metrics[query.Name] = metrics
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, boolToFloat64(c.metrics[query.Name] != nil), query.Name)
+		for _, metric := range c.metrics[query.Name] {
+			ch <- metric
+		}
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *QueryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.numFound
+	ch <- c.facetCount
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/orders/select" {
+			http.NotFound(w, r)
+			return
+		}
+		if rows := r.URL.Query().Get("rows"); rows != "0" {
+			t.Errorf("rows = %q, want 0", rows)
+		}
+		fmt.Fprintf(w, `{
+			"responseHeader": {"status": 0, "QTime": 3},
+			"response": {"numFound": 42, "start": 0, "docs": []},
+			"facets": {
+				"count": 42,
+				"status": {"buckets": [{"val": "shipped", "count": 30}, {"val": "pending", "count": 12}]},
+				"year": {"buckets": [{"val": 2018, "count": 42}]},
+				"urgent": {"count": 5}
+			}
+		}`)
+	}))
+	defer server.Close()
+
+	config, err := parseConfig([]byte(`{
+		"queries": [
+			{"name": "orders", "target": "orders", "params": {"q": "*:*", "json.facet": "{}"}},
+			{"name": "missing", "target": "unknown"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("parsing config: %v", err)
+	}
+	collector, err := NewQueryCollector(*http.DefaultClient, server.URL+"/solr", config.Queries)
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	metrics := collectMetrics(collector)
+	want := map[string]float64{
+		"solr_query_up{query=orders}":                                      1,
+		"solr_query_up{query=missing}":                                     0,
+		"solr_query_num_found{query=orders}":                               42,
+		"solr_query_facet_count{bucket=shipped,facet=status,query=orders}": 30,
+		"solr_query_facet_count{bucket=pending,facet=status,query=orders}": 12,
+		"solr_query_facet_count{bucket=2018,facet=year,query=orders}":      42,
+	}
+	if len(metrics) != len(want) {
+		t.Errorf("got %d metrics, want %d: %v", len(metrics), len(want), metrics)
+	}
+	for metric, value := range want {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if m.GetGauge().GetValue() != value {
+			t.Errorf("%s = %v, want %v", metric, m.GetGauge().GetValue(), value)
+		}
+	}
+}
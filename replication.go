@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var replicationPath = "/replication?command=details&wt=json"
+
+// Layout of java.util.Date#toString used by the replication handler, its zone
+// abbreviation is only resolved in the timezone of the solr JVM.
+const javaDateLayout = "Mon Jan 02 15:04:05 MST 2006"
+
+// Units used by solr to display human readable sizes.
+var readableSizeUnits = map[string]float64{
+	"bytes": 1,
+	"KB":    1 << 10,
+	"MB":    1 << 20,
+	"GB":    1 << 30,
+}
+
+// ReplicationCollector collects master/slave replication metrics from solr
+type ReplicationCollector struct {
+	isMaster                    *prometheus.Desc
+	isSlave                     *prometheus.Desc
+	masterIndexVersion          *prometheus.Desc
+	masterGeneration            *prometheus.Desc
+	slaveIndexVersion           *prometheus.Desc
+	slaveGeneration             *prometheus.Desc
+	secondsSinceLastReplication *prometheus.Desc
+	failures                    *prometheus.Desc
+	inProgress                  *prometheus.Desc
+	bytesDownloaded             *prometheus.Desc
+	timeRemaining               *prometheus.Desc
+
+	client         http.Client
+	adminCoresURL  string
+	replicationURL string
+	location       *time.Location
+}
+
+// NewReplicationCollector returns a new Collector exposing solr replication
+// statistics, timezone is the timezone of the solr JVM.
+func NewReplicationCollector(client http.Client, solrBaseURL string, timezone string) (*ReplicationCollector, error) {
+	adminCoresURL := fmt.Sprintf("%s%s", solrBaseURL, adminCoresPath)
+	replicationURL := fmt.Sprintf("%s/%s%s", solrBaseURL, "%s", replicationPath)
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid solr timezone %q: %v", timezone, err)
+	}
+	return &ReplicationCollector{
+		isMaster: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "is_master"),
+			"Whether the core is a replication master.",
+			[]string{"core"},
+			nil,
+		),
+		isSlave: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "is_slave"),
+			"Whether the core is a replication slave.",
+			[]string{"core"},
+			nil,
+		),
+		masterIndexVersion: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "master_index_version"),
+			"Index version of the replication master.",
+			[]string{"core"},
+			nil,
+		),
+		masterGeneration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "master_generation"),
+			"Index generation of the replication master.",
+			[]string{"core"},
+			nil,
+		),
+		slaveIndexVersion: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "slave_index_version"),
+			"Index version of the replication slave.",
+			[]string{"core"},
+			nil,
+		),
+		slaveGeneration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "slave_generation"),
+			"Index generation of the replication slave.",
+			[]string{"core"},
+			nil,
+		),
+		secondsSinceLastReplication: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "seconds_since_last_replication"),
+			"Seconds since the last successful replication of the slave.",
+			[]string{"core"},
+			nil,
+		),
+		failures: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "failures_total"),
+			"Number of failed replications of the slave.",
+			[]string{"core"},
+			nil,
+		),
+		inProgress: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "in_progress"),
+			"Whether a replication is in progress on the slave.",
+			[]string{"core"},
+			nil,
+		),
+		bytesDownloaded: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "bytes_downloaded"),
+			"Bytes downloaded by the replication in progress.",
+			[]string{"core"},
+			nil,
+		),
+		timeRemaining: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "replication", "time_remaining_seconds"),
+			"Estimated time remaining for the replication in progress.",
+			[]string{"core"},
+			nil,
+		),
+		client:         client,
+		adminCoresURL:  adminCoresURL,
+		replicationURL: replicationURL,
+		location:       location,
+	}, nil
+}
+
+// parseReadableSize converts sizes like "1.2 MB" or "1,023.9 MB" back into bytes.
+func parseReadableSize(size string) (float64, error) {
+	fields := strings.Fields(size)
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("Unexpected size %q", size)
+	}
+	unit, ok := readableSizeUnits[fields[1]]
+	if !ok {
+		return 0, fmt.Errorf("Unknown size unit %q", fields[1])
+	}
+	value, err := strconv.ParseFloat(strings.Replace(fields[0], ",", "", -1), 64)
+	if err != nil {
+		return 0, err
+	}
+	return value * unit, nil
+}
+
+// lastSuccessfulReplication returns the date of the newest replication that
+// did not fail, solr also sets indexReplicatedAt on the failed fetches.
+func (c *ReplicationCollector) lastSuccessfulReplication(replicatedAtList []LenientString, failedAtList []LenientString, replicatedAt LenientString, failedAt LenientString) (time.Time, bool) {
+	if len(replicatedAtList) == 0 {
+		if replicatedAt == "" || replicatedAt == failedAt {
+			return time.Time{}, false
+		}
+		replicatedAtList = []LenientString{replicatedAt}
+	}
+	failed := map[LenientString]bool{}
+	for _, date := range failedAtList {
+		failed[date] = true
+	}
+
+	var last time.Time
+	for _, date := range replicatedAtList {
+		if failed[date] {
+			continue
+		}
+		if replicated, err := time.ParseInLocation(javaDateLayout, string(date), c.location); err == nil && replicated.After(last) {
+			last = replicated
+		}
+	}
+	return last, !last.IsZero()
+}
+
+// Update exposes replication related metrics from solr.
+func (c *ReplicationCollector) Update(ch chan<- prometheus.Metric) error {
+	adminCoresStatus, err := getAdminCoresStatus(c.client, c.adminCoresURL)
+	if err != nil {
+		return err
+	}
+
+	for _, coreName := range getCoresFromStatus(adminCoresStatus) {
+		if isExcludedCore(coreName) {
+			continue
+		}
+
+		replication := &ReplicationDetails{}
+		if err := getJSON(c.client, fmt.Sprintf(c.replicationURL, coreName), replication); err != nil {
+			// Cores without a replication handler
+			if hasStatusCode(err, http.StatusNotFound) {
+				continue
+			}
+			log.Errorf("Error while querying Solr for replication details of core %s: %v", coreName, err)
+			continue
+		}
+
+		details := replication.Details
+		isMaster := details.IsMaster == "true"
+		isSlave := details.IsSlave == "true" && details.Slave != nil
+		ch <- prometheus.MustNewConstMetric(c.isMaster, prometheus.GaugeValue, boolToFloat64(isMaster), coreName)
+		ch <- prometheus.MustNewConstMetric(c.isSlave, prometheus.GaugeValue, boolToFloat64(isSlave), coreName)
+
+		if !isSlave {
+			if isMaster {
+				ch <- prometheus.MustNewConstMetric(c.masterIndexVersion, prometheus.GaugeValue, float64(details.IndexVersion), coreName)
+				ch <- prometheus.MustNewConstMetric(c.masterGeneration, prometheus.GaugeValue, float64(details.Generation), coreName)
+			}
+			continue
+		}
+
+		// Repeaters are both master and slave, the master index is then the remote one.
+		slave := details.Slave
+		ch <- prometheus.MustNewConstMetric(c.masterIndexVersion, prometheus.GaugeValue, float64(slave.MasterDetails.IndexVersion), coreName)
+		ch <- prometheus.MustNewConstMetric(c.masterGeneration, prometheus.GaugeValue, float64(slave.MasterDetails.Generation), coreName)
+		ch <- prometheus.MustNewConstMetric(c.slaveIndexVersion, prometheus.GaugeValue, float64(details.IndexVersion), coreName)
+		ch <- prometheus.MustNewConstMetric(c.slaveGeneration, prometheus.GaugeValue, float64(details.Generation), coreName)
+		ch <- prometheus.MustNewConstMetric(c.inProgress, prometheus.GaugeValue, boolToFloat64(slave.IsReplicating == "true"), coreName)
+
+		// timesFailed is missing until the first failure
+		timesFailed, _ := strconv.ParseFloat(string(slave.TimesFailed), 64)
+		ch <- prometheus.MustNewConstMetric(c.failures, prometheus.CounterValue, timesFailed, coreName)
+		if replicatedAt, ok := c.lastSuccessfulReplication(slave.IndexReplicatedAtList, slave.ReplicationFailedAtList, slave.IndexReplicatedAt, slave.ReplicationFailedAt); ok {
+			ch <- prometheus.MustNewConstMetric(c.secondsSinceLastReplication, prometheus.GaugeValue, time.Since(replicatedAt).Seconds(), coreName)
+		}
+		if bytesDownloaded, err := parseReadableSize(string(slave.BytesDownloaded)); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.bytesDownloaded, prometheus.GaugeValue, bytesDownloaded, coreName)
+		}
+		if timeRemaining, err := time.ParseDuration(string(slave.TimeRemaining)); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.timeRemaining, prometheus.GaugeValue, timeRemaining.Seconds(), coreName)
+		}
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *ReplicationCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect replication metrics: %v", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *ReplicationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.isMaster
+	ch <- c.isSlave
+	ch <- c.masterIndexVersion
+	ch <- c.masterGeneration
+	ch <- c.slaveIndexVersion
+	ch <- c.slaveGeneration
+	ch <- c.secondsSinceLastReplication
+	ch <- c.failures
+	ch <- c.inProgress
+	ch <- c.bytesDownloaded
+	ch <- c.timeRemaining
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseReadableSize(t *testing.T) {
+	tests := map[string]float64{
+		"512 bytes":  512,
+		"1.5 KB":     1.5 * 1024,
+		"1,023.9 MB": 1023.9 * 1024 * 1024,
+		"2 GB":       2 * 1024 * 1024 * 1024,
+	}
+
+	for size, want := range tests {
+		got, err := parseReadableSize(size)
+		if err != nil || math.Abs(got-want) > 1e-3 {
+			t.Errorf("parseReadableSize(%q) = %v, %v, want %v", size, got, err, want)
+		}
+	}
+	if _, err := parseReadableSize("12 parsecs"); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
+
+func TestReplicationCollector(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+	replicatedAt := time.Now().Add(-time.Hour).In(paris).Format(javaDateLayout)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/solr/admin/cores":
+			fmt.Fprintf(w, `{"status": {"products": {}}}`)
+		case "/solr/products/replication":
+			fmt.Fprintf(w, `{"details": {"isMaster": "false", "isSlave": "true", "indexVersion": 10, "generation": 2,
+				"slave": {"masterDetails": {"indexVersion": 12, "generation": 3}, "indexReplicatedAt": %q,
+					"isReplicating": "true", "bytesDownloaded": "1,023.9 MB", "timeRemaining": "30s"}}}`, replicatedAt)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	collector, err := NewReplicationCollector(*http.DefaultClient, server.URL+"/solr", "Europe/Paris")
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	metrics := collectMetrics(collector)
+	if got := metrics["solr_replication_seconds_since_last_replication{core=products}"].GetGauge().GetValue(); math.Abs(got-3600) > 60 {
+		t.Errorf("seconds since last replication = %v, want about 3600", got)
+	}
+	if got, want := metrics["solr_replication_bytes_downloaded{core=products}"].GetGauge().GetValue(), 1023.9*1024*1024; math.Abs(got-want) > 1e-3 {
+		t.Errorf("bytes downloaded = %v, want %v", got, want)
+	}
+}
+
+func TestReplicationCollectorFailedFetch(t *testing.T) {
+	now := time.Now().UTC()
+	succeeded := now.Add(-2 * time.Hour).Format(javaDateLayout)
+	failed := now.Add(-time.Minute).Format(javaDateLayout)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/solr/admin/cores":
+			fmt.Fprintf(w, `{"status": {"products": {}}}`)
+		case "/solr/products/replication":
+			// The failed fetch also sets indexReplicatedAt
+			fmt.Fprintf(w, `{"details": {"isMaster": "false", "isSlave": "true", "indexVersion": 10, "generation": 2,
+				"slave": {"masterDetails": {"indexVersion": 12, "generation": 3}, "indexReplicatedAt": %q,
+					"indexReplicatedAtList": [%q, %q], "replicationFailedAt": %q, "replicationFailedAtList": [%q],
+					"timesFailed": "1", "isReplicating": "false"}}}`, failed, failed, succeeded, failed, failed)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	collector, err := NewReplicationCollector(*http.DefaultClient, server.URL+"/solr", "UTC")
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	metrics := collectMetrics(collector)
+	if got := metrics["solr_replication_seconds_since_last_replication{core=products}"].GetGauge().GetValue(); math.Abs(got-7200) > 60 {
+		t.Errorf("seconds since last replication = %v, want about 7200", got)
+	}
+	if got := metrics["solr_replication_failures_total{core=products}"].GetCounter().GetValue(); got != 1 {
+		t.Errorf("failures = %v, want 1", got)
+	}
+}
+
+func TestReplicationCollectorInvalidTimezone(t *testing.T) {
+	if _, err := NewReplicationCollector(*http.DefaultClient, "http://localhost:8983/solr", "Mars/Olympus"); err == nil {
+		t.Error("expected an error for an unknown timezone")
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// NCSA request log line, optionally extended with the referer and user agent,
+// and followed by the latency in milliseconds when LogLatency is enabled.
+var requestLogRegexp = regexp.MustCompile(`^(\S+) \S+ \S+ \[[^\]]+\] "(\S+) (\S+)[^"]*" (\d{3}) \S+(?: "[^"]*" "[^"]*")?(?: (\d+))?\s*$`)
+
+var (
+	collectionsListPath = "/admin/collections?action=LIST&wt=json"
+
+	// Interval between two refreshes of the collections and cores served by solr.
+	requestLogRefreshInterval = time.Minute
+)
+
+// RequestLogCollector tails the jetty request log of a local solr, counting the
+// requests and observing their latency
+type RequestLogCollector struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	contextPath    string
+	clientNetworks []*net.IPNet
+	tailer         *Tailer
+
+	client             http.Client
+	adminCoresURL      string
+	collectionsListURL string
+	mutex              sync.Mutex
+	served             map[string]bool
+}
+
+// NewRequestLogCollector returns a new Collector exposing the requests of the
+// given request log, the clients are bucketed by network when clientNetworks
+// is not empty.
+func NewRequestLogCollector(client http.Client, solrBaseURL string, path string, contextPath string, clientNetworks []string) (*RequestLogCollector, error) {
+	labels := []string{"collection", "handler", "method", "status_class"}
+	networks := []*net.IPNet{}
+	for _, clientNetwork := range clientNetworks {
+		_, network, err := net.ParseCIDR(clientNetwork)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid client network %q: %v", clientNetwork, err)
+		}
+		networks = append(networks, network)
+	}
+	if len(networks) > 0 {
+		labels = append(labels, "client")
+	}
+
+	return &RequestLogCollector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "request_log",
+			Name:      "requests_total",
+			Help:      "Number of requests written to the jetty request log.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "request_log",
+			Name:      "duration_seconds",
+			Help:      "Latency of the requests written to the jetty request log, requires LogLatency.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		contextPath:        strings.TrimSuffix(contextPath, "/"),
+		clientNetworks:     networks,
+		tailer:             NewTailer(path),
+		client:             client,
+		adminCoresURL:      fmt.Sprintf("%s%s", solrBaseURL, adminCoresPath),
+		collectionsListURL: fmt.Sprintf("%s%s", solrBaseURL, collectionsListPath),
+		served:             map[string]bool{},
+	}, nil
+}
+
+// Run refreshes the collections and cores served by solr in the background
+// and tails the request log, it never returns.
+func (c *RequestLogCollector) Run() {
+	go func() {
+		ticker := time.NewTicker(requestLogRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := c.refresh(); err != nil {
+				log.Errorf("Failed to refresh the collections of the request log: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+	c.tailer.Run(time.Second, c.processLine)
+}
+
+// refresh updates the collections and cores served by solr, the only values
+// of the collection label.
+func (c *RequestLogCollector) refresh() error {
+	adminCoresStatus, err := getAdminCoresStatus(c.client, c.adminCoresURL)
+	if err != nil {
+		return err
+	}
+	served := map[string]bool{}
+	for _, core := range getCoresFromStatus(adminCoresStatus) {
+		served[core] = true
+	}
+
+	// Solr answers with a bad request when it is not running in SolrCloud mode
+	collectionsList := &CollectionsList{}
+	err = getJSON(c.client, c.collectionsListURL, collectionsList)
+	if err != nil && !hasStatusCode(err, http.StatusBadRequest) {
+		return fmt.Errorf("Error while querying Solr for collections: %v", err)
+	}
+	for _, collection := range collectionsList.Collections {
+		served[collection] = true
+	}
+
+	c.mutex.Lock()
+	c.served = served
+	c.mutex.Unlock()
+	return nil
+}
+
+// isServed reports whether the collection or core is served by solr.
+func (c *RequestLogCollector) isServed(collection string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.served[collection]
+}
+
+// requestPath splits the path of a request into its collection and handler.
+// The collections not served by solr are reported as other, the handlers
+// are bounded by processLine for the failed requests.
+func (c *RequestLogCollector) requestPath(path string) (string, string) {
+	if i := strings.IndexAny(path, "?;"); i >= 0 {
+		path = path[:i]
+	}
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	if !strings.HasPrefix(path, c.contextPath+"/") {
+		return "", "other"
+	}
+
+	segments := strings.Split(strings.Trim(path[len(c.contextPath):], "/"), "/")
+	// Files of the admin UI
+	if strings.Contains(segments[len(segments)-1], ".") {
+		return "", "static"
+	}
+	collection := ""
+	switch segments[0] {
+	case "", "admin", "api", "____v2":
+	default:
+		collection, segments = segments[0], segments[1:]
+		if !c.isServed(collection) {
+			collection = "other"
+		}
+	}
+	if len(segments) == 0 || segments[0] == "" {
+		return collection, "/"
+	}
+	// Keep the sub handler of admin handlers, like /admin/ping or /admin/cores.
+	if segments[0] == "admin" && len(segments) > 1 {
+		return collection, "/admin/" + segments[1]
+	}
+	return collection, "/" + segments[0]
+}
+
+// clientBucket returns the network of the client, or other.
+func (c *RequestLogCollector) clientBucket(address string) string {
+	ip := net.ParseIP(address)
+	for _, network := range c.clientNetworks {
+		if ip != nil && network.Contains(ip) {
+			return network.String()
+		}
+	}
+	return "other"
+}
+
+// processLine updates the metrics from a line of the request log.
+func (c *RequestLogCollector) processLine(line string) {
+	match := requestLogRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	client, method, path, status, latency := match[1], match[2], match[3], match[4], match[5]
+
+	collection, handler := c.requestPath(path)
+	// Client errors on unknown paths, like scanners or typos, would create
+	// new handler values forever.
+	if status[0] == '4' && (status == "404" || collection == "other") {
+		handler = "other"
+	}
+	labels := []string{collection, handler, method, status[:1] + "xx"}
+	if len(c.clientNetworks) > 0 {
+		labels = append(labels, c.clientBucket(client))
+	}
+
+	c.requests.WithLabelValues(labels...).Inc()
+	if milliseconds, err := strconv.ParseFloat(latency, 64); err == nil {
+		c.duration.WithLabelValues(labels...).Observe(milliseconds / 1000)
+	}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *RequestLogCollector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *RequestLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestLogCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/solr/admin/cores":
+			fmt.Fprintf(w, `{"status": {"products_shard1_replica_n1": {}, "orders": {}}}`)
+		case "/solr/admin/collections":
+			fmt.Fprintf(w, `{"collections": ["products"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	collector, err := NewRequestLogCollector(*http.DefaultClient, server.URL+"/solr", "/nonexistent/*.request.log", "/solr", []string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+	if err := collector.refresh(); err != nil {
+		t.Fatalf("refreshing collections: %v", err)
+	}
+
+	for _, line := range []string{
+		`10.1.2.3 - - [01/Oct/2018:12:00:00 +0000] "GET /solr/products/select?q=*:*&wt=json HTTP/1.1" 200 1234 12`,
+		`10.1.2.4 - - [01/Oct/2018:12:00:01 +0000] "GET /solr/products/select?q=foo:bar HTTP/1.1" 400 321 "-" "curl/7.58.0" 3`,
+		`192.168.1.1 - - [01/Oct/2018:12:00:02 +0000] "POST /solr/orders/update?commit=true HTTP/1.1" 200 150 1500`,
+		`10.1.2.3 - - [01/Oct/2018:12:00:03 +0000] "GET /solr/admin/cores?action=STATUS HTTP/1.1" 200 5000 7`,
+		`10.1.2.3 - - [01/Oct/2018:12:00:04 +0000] "GET /solr/products/admin/ping HTTP/1.1" 200 100 2`,
+		`10.1.2.3 - - [01/Oct/2018:12:00:05 +0000] "GET /solr/libs/angular.min.js HTTP/1.1" 304 0 1`,
+		// Without LogLatency, the request is only counted.
+		`10.1.2.3 - - [01/Oct/2018:12:00:06 +0000] "GET /solr/products/select?q=*:* HTTP/1.1" 200 1234`,
+		// Unknown collections and paths, with bounded labels.
+		`10.9.9.9 - - [01/Oct/2018:12:00:07 +0000] "GET /solr/x123/wp-login HTTP/1.1" 404 0 1`,
+		`10.9.9.9 - - [01/Oct/2018:12:00:08 +0000] "GET /solr/foo/bar HTTP/1.1" 404 0 1`,
+		`10.9.9.9 - - [01/Oct/2018:12:00:09 +0000] "GET /solr/products/nothing-here HTTP/1.1" 404 0 1`,
+		`10.9.9.9 - - [01/Oct/2018:12:00:10 +0000] "GET /solr/admin/unknown HTTP/1.1" 404 0 1`,
+		"not a request log line",
+	} {
+		collector.processLine(line)
+	}
+
+	metrics := collectMetrics(collector)
+	for metric, want := range map[string]float64{
+		"solr_request_log_requests_total{client=10.0.0.0/8,collection=products,handler=/select,method=GET,status_class=2xx}":     2,
+		"solr_request_log_requests_total{client=10.0.0.0/8,collection=products,handler=/select,method=GET,status_class=4xx}":     1,
+		"solr_request_log_requests_total{client=other,collection=orders,handler=/update,method=POST,status_class=2xx}":           1,
+		"solr_request_log_requests_total{client=10.0.0.0/8,collection=,handler=/admin/cores,method=GET,status_class=2xx}":        1,
+		"solr_request_log_requests_total{client=10.0.0.0/8,collection=products,handler=/admin/ping,method=GET,status_class=2xx}": 1,
+		"solr_request_log_requests_total{client=10.0.0.0/8,collection=,handler=static,method=GET,status_class=3xx}":              1,
+		"solr_request_log_requests_total{client=10.0.0.0/8,collection=other,handler=other,method=GET,status_class=4xx}":          2,
+		"solr_request_log_requests_total{client=10.0.0.0/8,collection=products,handler=other,method=GET,status_class=4xx}":       1,
+		"solr_request_log_requests_total{client=10.0.0.0/8,collection=,handler=other,method=GET,status_class=4xx}":               1,
+	} {
+		if got := metrics[metric].GetCounter().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	for metric, want := range map[string]float64{
+		"solr_request_log_duration_seconds{client=10.0.0.0/8,collection=products,handler=/select,method=GET,status_class=2xx}": 0.012,
+		"solr_request_log_duration_seconds{client=other,collection=orders,handler=/update,method=POST,status_class=2xx}":       1.5,
+	} {
+		histogram := metrics[metric].GetHistogram()
+		if histogram.GetSampleCount() != 1 || math.Abs(histogram.GetSampleSum()-want) > 1e-9 {
+			t.Errorf("%s count = %d, sum = %v, want a single %v", metric, histogram.GetSampleCount(), histogram.GetSampleSum(), want)
+		}
+	}
+	if len(metrics) != 18 {
+		t.Errorf("got %d metrics, want 18: %v", len(metrics), metrics)
+	}
+}
+
+func TestRequestLogCollectorInvalidNetwork(t *testing.T) {
+	if _, err := NewRequestLogCollector(*http.DefaultClient, "http://localhost:8983/solr", "request.log", "/solr", []string{"10.0.0.0"}); err == nil {
+		t.Error("expected an error for a network without prefix length")
+	}
+}
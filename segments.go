@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var segmentsPath = "/admin/segments?wt=json"
+
+var (
+	segmentSizeBuckets        = prometheus.ExponentialBuckets(1<<20, 4, 9)
+	segmentDeletedDocsBuckets = prometheus.ExponentialBuckets(10, 10, 7)
+)
+
+// SegmentsCollector collects lucene segments metrics from solr
+type SegmentsCollector struct {
+	count            *prometheus.Desc
+	sizeBytes        *prometheus.Desc
+	deletedDocs      *prometheus.Desc
+	mergeCandidates  *prometheus.Desc
+	largestSizeBytes *prometheus.Desc
+	oldestAgeSeconds *prometheus.Desc
+
+	client        http.Client
+	adminCoresURL string
+	segmentsURL   string
+}
+
+// NewSegmentsCollector returns a new Collector exposing solr segments statistics.
+func NewSegmentsCollector(client http.Client, solrBaseURL string) (*SegmentsCollector, error) {
+	adminCoresURL := fmt.Sprintf("%s%s", solrBaseURL, adminCoresPath)
+	segmentsURL := fmt.Sprintf("%s/%s%s", solrBaseURL, "%s", segmentsPath)
+	return &SegmentsCollector{
+		count: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "segments", "count"),
+			"Number of segments of the core index.",
+			[]string{"core"},
+			nil,
+		),
+		sizeBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "segments", "size_bytes"),
+			"Distribution of the segments size in bytes.",
+			[]string{"core"},
+			nil,
+		),
+		deletedDocs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "segments", "deleted_docs"),
+			"Distribution of the number of deleted documents per segment.",
+			[]string{"core"},
+			nil,
+		),
+		mergeCandidates: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "segments", "merge_candidates"),
+			"Number of segments selected by the merge policy for the next merge.",
+			[]string{"core"},
+			nil,
+		),
+		largestSizeBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "segments", "largest_size_bytes"),
+			"Size of the largest segment in bytes.",
+			[]string{"core"},
+			nil,
+		),
+		oldestAgeSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "segments", "oldest_age_seconds"),
+			"Age of the oldest segment in seconds.",
+			[]string{"core"},
+			nil,
+		),
+		client:        client,
+		adminCoresURL: adminCoresURL,
+		segmentsURL:   segmentsURL,
+	}, nil
+}
+
+// histogramBuckets returns the cumulative bucket counts and the sum of values,
+// as expected by prometheus.MustNewConstHistogram.
+func histogramBuckets(values []float64, bounds []float64) (map[float64]uint64, float64) {
+	buckets := make(map[float64]uint64, len(bounds))
+	sum := 0.0
+	for _, bound := range bounds {
+		buckets[bound] = 0
+	}
+	for _, value := range values {
+		sum += value
+		for _, bound := range bounds {
+			if value <= bound {
+				buckets[bound]++
+			}
+		}
+	}
+	return buckets, sum
+}
+
+// Update exposes segments related metrics from solr.
+func (c *SegmentsCollector) Update(ch chan<- prometheus.Metric) error {
+	adminCoresStatus, err := getAdminCoresStatus(c.client, c.adminCoresURL)
+	if err != nil {
+		return err
+	}
+
+	for _, coreName := range getCoresFromStatus(adminCoresStatus) {
+		if isExcludedCore(coreName) {
+			continue
+		}
+
+		segmentsInfo := &SegmentsInfo{}
+		if err := getJSON(c.client, fmt.Sprintf(c.segmentsURL, coreName), segmentsInfo); err != nil {
+			log.Errorf("Error while querying Solr for segments of core %s: %v", coreName, err)
+			continue
+		}
+
+		sizes := []float64{}
+		deletedDocs := []float64{}
+		mergeCandidates := 0
+		var largest int64
+		var oldest time.Time
+		for _, segment := range segmentsInfo.Segments {
+			sizes = append(sizes, float64(segment.SizeInBytes))
+			deletedDocs = append(deletedDocs, float64(segment.DelCount))
+			if segment.MergeCandidate {
+				mergeCandidates++
+			}
+			if segment.SizeInBytes > largest {
+				largest = segment.SizeInBytes
+			}
+			if age, err := time.Parse(time.RFC3339, segment.Age); err == nil && (oldest.IsZero() || age.Before(oldest)) {
+				oldest = age
+			}
+		}
+
+		sizeBuckets, sizeSum := histogramBuckets(sizes, segmentSizeBuckets)
+		deletedBuckets, deletedSum := histogramBuckets(deletedDocs, segmentDeletedDocsBuckets)
+		ch <- prometheus.MustNewConstMetric(c.count, prometheus.GaugeValue, float64(len(segmentsInfo.Segments)), coreName)
+		ch <- prometheus.MustNewConstHistogram(c.sizeBytes, uint64(len(sizes)), sizeSum, sizeBuckets, coreName)
+		ch <- prometheus.MustNewConstHistogram(c.deletedDocs, uint64(len(deletedDocs)), deletedSum, deletedBuckets, coreName)
+		ch <- prometheus.MustNewConstMetric(c.mergeCandidates, prometheus.GaugeValue, float64(mergeCandidates), coreName)
+		ch <- prometheus.MustNewConstMetric(c.largestSizeBytes, prometheus.GaugeValue, float64(largest), coreName)
+		if !oldest.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.oldestAgeSeconds, prometheus.GaugeValue, time.Since(oldest).Seconds(), coreName)
+		}
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *SegmentsCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect segments metrics: %v", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *SegmentsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.count
+	ch <- c.sizeBytes
+	ch <- c.deletedDocs
+	ch <- c.mergeCandidates
+	ch <- c.largestSizeBytes
+	ch <- c.oldestAgeSeconds
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+	buckets, sum := histogramBuckets([]float64{0, 5, 10, 11, 250, 5000}, []float64{10, 100, 1000})
+	want := map[float64]uint64{10: 3, 100: 4, 1000: 5}
+	if !reflect.DeepEqual(buckets, want) {
+		t.Errorf("buckets = %v, want %v", buckets, want)
+	}
+	if sum != 5276 {
+		t.Errorf("sum = %v, want 5276", sum)
+	}
+
+	buckets, sum = histogramBuckets(nil, []float64{10, 100})
+	if want := map[float64]uint64{10: 0, 100: 0}; !reflect.DeepEqual(buckets, want) || sum != 0 {
+		t.Errorf("empty histogram = %v, %v, want %v, 0", buckets, sum, want)
+	}
+}
+
+func TestSegmentsCollector(t *testing.T) {
+	oldest := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	newest := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/solr/admin/cores":
+			fmt.Fprint(w, `{"status": {"products": {}}}`)
+		case "/solr/products/admin/segments":
+			fmt.Fprintf(w, `{"segments": {
+				"_0": {"name": "_0", "delCount": 1500, "sizeInBytes": 734003200, "size": 90000, "age": %q, "source": "merge", "mergeCandidate": true},
+				"_1": {"name": "_1", "delCount": 3, "sizeInBytes": 2097152, "size": 400, "age": %q, "source": "flush"}}}`, oldest, newest)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	collector, _ := NewSegmentsCollector(*http.DefaultClient, server.URL+"/solr")
+	metrics := collectMetrics(collector)
+
+	for metric, want := range map[string]float64{
+		"solr_segments_count{core=products}":              2,
+		"solr_segments_merge_candidates{core=products}":   1,
+		"solr_segments_largest_size_bytes{core=products}": 734003200,
+	} {
+		if got := metrics[metric].GetGauge().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	if got := metrics["solr_segments_oldest_age_seconds{core=products}"].GetGauge().GetValue(); math.Abs(got-48*3600) > 60 {
+		t.Errorf("oldest age = %v, want about %v", got, 48*3600)
+	}
+
+	deleted := metrics["solr_segments_deleted_docs{core=products}"].GetHistogram()
+	if deleted.GetSampleCount() != 2 || deleted.GetSampleSum() != 1503 {
+		t.Errorf("deleted docs count = %d, sum = %v, want 2, 1503", deleted.GetSampleCount(), deleted.GetSampleSum())
+	}
+	for _, bucket := range deleted.GetBucket() {
+		want := uint64(2)
+		if bucket.GetUpperBound() < 1500 {
+			want = 1
+		}
+		if bucket.GetCumulativeCount() != want {
+			t.Errorf("deleted docs bucket %v = %d, want %d", bucket.GetUpperBound(), bucket.GetCumulativeCount(), want)
+		}
+	}
+}
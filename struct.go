@@ -4,13 +4,30 @@ import "encoding/json"
 
 type AdminCoresStatus struct {
 	Status map[string]struct {
-		Index struct {
-			SizeInBytes int64 `json:"sizeInBytes"`
-			NumDocs     int   `json:"numDocs"`
-			MaxDoc      int   `json:"maxDoc"`
-			DeletedDocs int   `json:"deletedDocs"`
+		InstanceDir string `json:"instanceDir"`
+		DataDir     string `json:"dataDir"`
+		Config      string `json:"config"`
+		Schema      string `json:"schema"`
+		StartTime   string `json:"startTime"`
+		Uptime      int64  `json:"uptime"`
+		Index       struct {
+			SizeInBytes         int64  `json:"sizeInBytes"`
+			NumDocs             int    `json:"numDocs"`
+			MaxDoc              int    `json:"maxDoc"`
+			DeletedDocs         int    `json:"deletedDocs"`
+			Version             int64  `json:"version"`
+			SegmentsFile        string `json:"segmentsFile"`
+			SegmentCount        int    `json:"segmentCount"`
+			Current             bool   `json:"current"`
+			HasDeletions        bool   `json:"hasDeletions"`
+			IndexHeapUsageBytes int64  `json:"indexHeapUsageBytes"`
+			LastModified        string `json:"lastModified"`
+			UserData            struct {
+				CommitTimeMSec LenientString `json:"commitTimeMSec"`
+			} `json:"userData"`
 		} `json:"index"`
 	} `json:"status"`
+	InitFailures map[string]string `json:"initFailures"`
 }
 
 type MBeansData struct {
@@ -267,4 +284,247 @@ type InfoSystem struct {
 	Lucene struct {
 		SolrVersion string `json:"solr-spec-version"`
 	} `json:"lucene"`
+	JVM struct {
+		JMX struct {
+			StartTime string `json:"startTime"`
+			UpTimeMS  int64  `json:"upTimeMS"`
+		} `json:"jmx"`
+	} `json:"jvm"`
+}
+
+type CollectionsList struct {
+	Collections []string `json:"collections"`
+}
+
+type ClusterStatus struct {
+	Cluster struct {
+		Collections map[string]ClusterCollection `json:"collections"`
+		LiveNodes   []string                     `json:"live_nodes"`
+	} `json:"cluster"`
+}
+
+type ClusterCollection struct {
+	ConfigName string `json:"configName"`
+	Router     struct {
+		Name string `json:"name"`
+	} `json:"router"`
+	Shards map[string]ClusterShard `json:"shards"`
+}
+
+type ClusterShard struct {
+	State    string                    `json:"state"`
+	Replicas map[string]ClusterReplica `json:"replicas"`
+}
+
+type ClusterReplica struct {
+	Core     string `json:"core"`
+	BaseURL  string `json:"base_url"`
+	NodeName string `json:"node_name"`
+	State    string `json:"state"`
+	Type     string `json:"type"`
+	Leader   string `json:"leader"`
+}
+
+// IsLeader reports whether the replica is the leader of its shard.
+func (r ClusterReplica) IsLeader() bool {
+	return r.Leader == "true"
+}
+
+// ReplicaType returns the replica type, Solr < 7 only knows about NRT replicas.
+func (r ClusterReplica) ReplicaType() string {
+	if r.Type == "" {
+		return "NRT"
+	}
+	return r.Type
+}
+
+type OverseerStatus struct {
+	Leader                      string            `json:"leader"`
+	OverseerQueueSize           int64             `json:"overseer_queue_size"`
+	OverseerWorkQueueSize       int64             `json:"overseer_work_queue_size"`
+	OverseerCollectionQueueSize int64             `json:"overseer_collection_queue_size"`
+	OverseerOperations          []json.RawMessage `json:"overseer_operations"`
+	CollectionOperations        []json.RawMessage `json:"collection_operations"`
+	OverseerQueue               []json.RawMessage `json:"overseer_queue"`
+	OverseerInternalQueue       []json.RawMessage `json:"overseer_internal_queue"`
+	CollectionQueue             []json.RawMessage `json:"collection_queue"`
+}
+
+type OverseerOperation struct {
+	Requests              int64   `json:"requests"`
+	Errors                int64   `json:"errors"`
+	AvgTimePerRequest     float64 `json:"avgTimePerRequest"`
+	MedianRequestTime     float64 `json:"medianRequestTime"`
+	Seven5thPcRequestTime float64 `json:"75thPcRequestTime"`
+	Nine5thPcRequestTime  float64 `json:"95thPcRequestTime"`
+	Nine9thPcRequestTime  float64 `json:"99thPcRequestTime"`
+	Nine99thPcRequestTime float64 `json:"999thPcRequestTime"`
+}
+
+type ZookeeperStatus struct {
+	ZkStatus struct {
+		EnsembleSize int                      `json:"ensembleSize"`
+		ZkHost       string                   `json:"zkHost"`
+		Mode         string                   `json:"mode"`
+		Status       string                   `json:"status"`
+		Details      []map[string]interface{} `json:"details"`
+	} `json:"zkStatus"`
+}
+
+type ZookeeperZnode struct {
+	Znode struct {
+		Path string `json:"path"`
+		Prop struct {
+			DataLength    int64 `json:"dataLength"`
+			ChildrenCount int64 `json:"children_count"`
+		} `json:"prop"`
+	} `json:"znode"`
+	Tree []ZookeeperTree `json:"tree"`
+}
+
+type ZookeeperTree struct {
+	Data struct {
+		Title string `json:"title"`
+	} `json:"data"`
+	Children []ZookeeperTree `json:"children"`
+}
+
+// LenientString accepts JSON strings, numbers and booleans, some solr handlers
+// use them interchangeably depending on the version.
+type LenientString string
+
+func (s *LenientString) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = LenientString(str)
+		return nil
+	}
+	*s = LenientString(data)
+	return nil
+}
+
+type ReplicationDetails struct {
+	Details struct {
+		IsMaster     LenientString `json:"isMaster"`
+		IsSlave      LenientString `json:"isSlave"`
+		IndexVersion int64         `json:"indexVersion"`
+		Generation   int64         `json:"generation"`
+		Slave        *struct {
+			MasterDetails struct {
+				IndexVersion int64 `json:"indexVersion"`
+				Generation   int64 `json:"generation"`
+			} `json:"masterDetails"`
+			IndexReplicatedAt       LenientString   `json:"indexReplicatedAt"`
+			IndexReplicatedAtList   []LenientString `json:"indexReplicatedAtList"`
+			ReplicationFailedAt     LenientString   `json:"replicationFailedAt"`
+			ReplicationFailedAtList []LenientString `json:"replicationFailedAtList"`
+			TimesFailed             LenientString   `json:"timesFailed"`
+			IsReplicating           LenientString   `json:"isReplicating"`
+			BytesDownloaded         LenientString   `json:"bytesDownloaded"`
+			TimeRemaining           LenientString   `json:"timeRemaining"`
+		} `json:"slave"`
+	} `json:"details"`
+}
+
+type SegmentsInfo struct {
+	Segments map[string]struct {
+		Name           string `json:"name"`
+		DelCount       int64  `json:"delCount"`
+		SizeInBytes    int64  `json:"sizeInBytes"`
+		Size           int64  `json:"size"`
+		Age            string `json:"age"`
+		MergeCandidate bool   `json:"mergeCandidate"`
+	} `json:"segments"`
+}
+
+type LukeInfo struct {
+	Index struct {
+		LastModified string `json:"lastModified"`
+	} `json:"index"`
+	Fields map[string]struct {
+		Type        string `json:"type"`
+		DynamicBase string `json:"dynamicBase"`
+		Docs        int64  `json:"docs"`
+	} `json:"fields"`
+}
+
+type PingStatus struct {
+	Status string `json:"status"`
+}
+
+type QueryResponse struct {
+	ResponseHeader struct {
+		Status int   `json:"status"`
+		QTime  int64 `json:"QTime"`
+	} `json:"responseHeader"`
+	Response struct {
+		NumFound int64                    `json:"numFound"`
+		Docs     []map[string]interface{} `json:"docs"`
+	} `json:"response"`
+	Facets map[string]json.RawMessage `json:"facets"`
+	Debug  struct {
+		Timing map[string]json.RawMessage `json:"timing"`
+	} `json:"debug"`
+}
+
+type ComponentTiming struct {
+	Time float64 `json:"time"`
+}
+
+type FacetBuckets struct {
+	Buckets []struct {
+		Val   LenientString `json:"val"`
+		Count int64         `json:"count"`
+	} `json:"buckets"`
+}
+
+type RealTimeGetResponse struct {
+	Doc map[string]interface{} `json:"doc"`
+}
+
+type UpdateResponse struct {
+	ResponseHeader struct {
+		Status int `json:"status"`
+	} `json:"responseHeader"`
+}
+
+type DataImportStatus struct {
+	Status         string            `json:"status"`
+	StatusMessages map[string]string `json:"statusMessages"`
+}
+
+type ThreadDump struct {
+	System struct {
+		Deadlocks  []json.RawMessage `json:"deadlocks"`
+		ThreadDump []json.RawMessage `json:"threadDump"`
+	} `json:"system"`
+}
+
+type ThreadInfo struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	Lock        string `json:"lock"`
+	LockWaiting *struct {
+		Name  string `json:"name"`
+		Owner *struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"owner"`
+	} `json:"lock-waiting"`
+}
+
+type LoggingHistory struct {
+	Info struct {
+		Last int64 `json:"last"`
+	} `json:"info"`
+	History struct {
+		Docs []struct {
+			Time    string `json:"time"`
+			Level   string `json:"level"`
+			Logger  string `json:"logger"`
+			Message string `json:"message"`
+			Trace   string `json:"trace"`
+		} `json:"docs"`
+	} `json:"history"`
 }
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+// Tailer follows the lines appended to a file, like tail -F it reopens the file
+// when it gets rotated and starts over when it gets truncated. The path may
+// also be a glob pattern, like for files named after the date, the most
+// recently modified matching file is then followed.
+type Tailer struct {
+	path string
+
+	file    *os.File
+	reader  *bufio.Reader
+	info    os.FileInfo
+	offset  int64
+	partial string
+	started bool
+}
+
+// NewTailer returns a Tailer of the file at path. The lines already written
+// when the file is first opened are skipped.
+func NewTailer(path string) *Tailer {
+	return &Tailer{path: path}
+}
+
+// currentPath returns the most recently modified file matching the path.
+func (t *Tailer) currentPath() (string, error) {
+	matches, err := filepath.Glob(t.path)
+	if err != nil {
+		return "", err
+	}
+	current := ""
+	var currentModTime time.Time
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if current == "" || info.ModTime().After(currentModTime) {
+			current = match
+			currentModTime = info.ModTime()
+		}
+	}
+	if current == "" {
+		return "", &os.PathError{Op: "open", Path: t.path, Err: os.ErrNotExist}
+	}
+	return current, nil
+}
+
+// open opens the file, at its end when the tailer starts and at its beginning
+// after a rotation.
+func (t *Tailer) open() error {
+	path, err := t.currentPath()
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	offset := int64(0)
+	if !t.started {
+		if offset, err = file.Seek(0, io.SeekEnd); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	t.file = file
+	t.reader = bufio.NewReader(file)
+	t.info = info
+	t.offset = offset
+	t.partial = ""
+	t.started = true
+	return nil
+}
+
+// readLines reads the complete lines available, keeping a trailing partial line
+// for the next read.
+func (t *Tailer) readLines(handle func(line string)) error {
+	for {
+		line, err := t.reader.ReadString('\n')
+		t.offset += int64(len(line))
+		if err == io.EOF {
+			t.partial += line
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = t.partial + line[:len(line)-1]
+		t.partial = ""
+		if len(line) > 0 && line[len(line)-1] == '\r' {
+			line = line[:len(line)-1]
+		}
+		handle(line)
+	}
+}
+
+// Poll hands the lines appended since the last poll to handle.
+func (t *Tailer) Poll(handle func(line string)) error {
+	if t.file == nil {
+		if err := t.open(); err != nil {
+			// Not created yet or rotated away, the file is looked for again on the next poll.
+			if os.IsNotExist(err) {
+				t.started = true
+				return nil
+			}
+			return err
+		}
+	}
+
+	if err := t.readLines(handle); err != nil {
+		return err
+	}
+
+	path, err := t.currentPath()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !os.SameFile(t.info, info) {
+		// Rotated, the rest of the old file has been read above.
+		t.file.Close()
+		t.file = nil
+		if err := t.open(); err != nil {
+			return err
+		}
+		return t.readLines(handle)
+	}
+
+	if info.Size() < t.offset {
+		// Truncated, starts over from the beginning.
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		t.reader.Reset(t.file)
+		t.offset = 0
+		t.partial = ""
+		return t.readLines(handle)
+	}
+
+	return nil
+}
+
+// Run polls the file every interval, it never returns.
+func (t *Tailer) Run(interval time.Duration, handle func(line string)) {
+	for {
+		if err := t.Poll(handle); err != nil {
+			log.Errorf("Failed to tail %s: %v", t.path, err)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func appendFile(t *testing.T, path string, content string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestTailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailer")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "solr.log")
+
+	tailer := NewTailer(path)
+	lines := []string{}
+	poll := func(want ...string) {
+		t.Helper()
+		lines = []string{}
+		if err := tailer.Poll(func(line string) { lines = append(lines, line) }); err != nil {
+			t.Fatalf("polling: %v", err)
+		}
+		if len(want) == 0 && len(lines) == 0 {
+			return
+		}
+		if !reflect.DeepEqual(lines, want) {
+			t.Errorf("got lines %q, want %q", lines, want)
+		}
+	}
+
+	appendFile(t, path, "before start\n")
+	poll()
+
+	appendFile(t, path, "first\nsec")
+	poll("first")
+	appendFile(t, path, "ond\n")
+	poll("second")
+
+	// Rotation, the end of the old file is still read.
+	appendFile(t, path, "last of old\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("rotating: %v", err)
+	}
+	appendFile(t, path, "first of new\n")
+	poll("last of old", "first of new")
+
+	// Truncation
+	if err := ioutil.WriteFile(path, []byte("a\n"), 0644); err != nil {
+		t.Fatalf("truncating: %v", err)
+	}
+	poll("a")
+}
+
+func TestTailerGlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailer")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tailer := NewTailer(filepath.Join(dir, "*.request.log"))
+	lines := []string{}
+	handle := func(line string) { lines = append(lines, line) }
+
+	// Nothing matches yet, the first file is then read from its beginning.
+	if err := tailer.Poll(handle); err != nil {
+		t.Fatalf("polling: %v", err)
+	}
+	first := filepath.Join(dir, "2018_10_01.request.log")
+	appendFile(t, first, "first day\n")
+	if err := tailer.Poll(handle); err != nil {
+		t.Fatalf("polling: %v", err)
+	}
+
+	// The next day file is followed once it is the most recently modified.
+	appendFile(t, first, "end of first day\n")
+	second := filepath.Join(dir, "2018_10_02.request.log")
+	appendFile(t, second, "second day\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(second, later, later); err != nil {
+		t.Fatalf("touching %s: %v", second, err)
+	}
+	if err := tailer.Poll(handle); err != nil {
+		t.Fatalf("polling: %v", err)
+	}
+
+	want := []string{"first day", "end of first day", "second day"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+}
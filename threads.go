@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var threadsPath = "/admin/info/threads?wt=json"
+
+var (
+	threadPoolQTPRegexp    = regexp.MustCompile(`^qtp\d+`)
+	threadPoolObjectRegexp = regexp.MustCompile(`@[0-9a-f]+`)
+	threadPoolSuffixRegexp = regexp.MustCompile(`(?:[-_ #]*(?:\d+|thread))+$`)
+)
+
+// ThreadsCollector collects thread dump metrics from solr
+type ThreadsCollector struct {
+	threads       *prometheus.Desc
+	blockedByLock *prometheus.Desc
+	deadlocked    *prometheus.Desc
+
+	client     http.Client
+	threadsURL string
+}
+
+// NewThreadsCollector returns a new Collector exposing solr threads statistics.
+func NewThreadsCollector(client http.Client, solrBaseURL string) (*ThreadsCollector, error) {
+	threadsURL := fmt.Sprintf("%s%s", solrBaseURL, threadsPath)
+	return &ThreadsCollector{
+		threads: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "threads"),
+			"Number of threads by pool and state.",
+			[]string{"pool", "state"},
+			nil,
+		),
+		blockedByLock: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "threads", "blocked_by_lock_owner"),
+			"Number of blocked threads waiting for a lock held by the thread (Solr >= 8.0).",
+			[]string{"lock_owner"},
+			nil,
+		),
+		deadlocked: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "threads", "deadlocked"),
+			"Thread involved in a deadlock.",
+			[]string{"pool", "thread"},
+			nil,
+		),
+		client:     client,
+		threadsURL: threadsURL,
+	}, nil
+}
+
+// threadPool normalizes a thread name into the name of its pool, like
+// searcherExecutor for searcherExecutor-7-thread-1-processing-x:products.
+func threadPool(name string) string {
+	// Jetty also appends the connector to the names of some threads, like
+	// qtp1989972246-17-acceptor-0@5b3a4b2d-ServerConnector.
+	if threadPoolQTPRegexp.MatchString(name) {
+		return "qtp"
+	}
+
+	pool := name
+	if i := strings.Index(pool, "-processing-"); i >= 0 {
+		pool = pool[:i]
+	}
+	if i := strings.Index(pool, "("); i >= 0 {
+		pool = pool[:i]
+	}
+	pool = threadPoolObjectRegexp.ReplaceAllString(pool, "")
+	pool = strings.TrimSpace(threadPoolSuffixRegexp.ReplaceAllString(pool, ""))
+	if pool == "" {
+		return "other"
+	}
+	return pool
+}
+
+// parseThreadInfos decodes the thread infos of a flattened named list, in
+// which thread names and infos alternate.
+func parseThreadInfos(namedList []json.RawMessage) []ThreadInfo {
+	threads := []ThreadInfo{}
+	for _, raw := range namedList {
+		thread := ThreadInfo{}
+		if err := json.Unmarshal(raw, &thread); err != nil || thread.Name == "" {
+			continue
+		}
+		threads = append(threads, thread)
+	}
+	return threads
+}
+
+// lockOwner returns the name of the thread holding the lock the thread is
+// waiting for. Solr only reports it in lock-waiting since 8.0, the 7.x thread
+// dumps have the lock but not its owner.
+func lockOwner(thread ThreadInfo) string {
+	if thread.LockWaiting == nil || thread.LockWaiting.Owner == nil {
+		return ""
+	}
+	return thread.LockWaiting.Owner.Name
+}
+
+// Update exposes threads related metrics from solr.
+func (c *ThreadsCollector) Update(ch chan<- prometheus.Metric) error {
+	threadDump := &ThreadDump{}
+	if err := getJSON(c.client, c.threadsURL, threadDump); err != nil {
+		return fmt.Errorf("Error while querying Solr for threads: %v", err)
+	}
+
+	type poolState struct{ pool, state string }
+	threads := map[poolState]int{}
+	blockedByLock := map[string]int{}
+	for _, thread := range parseThreadInfos(threadDump.System.ThreadDump) {
+		threads[poolState{threadPool(thread.Name), thread.State}]++
+		if owner := lockOwner(thread); thread.State == "BLOCKED" && owner != "" {
+			blockedByLock[owner]++
+		}
+	}
+
+	for key, count := range threads {
+		ch <- prometheus.MustNewConstMetric(c.threads, prometheus.GaugeValue, float64(count), key.pool, key.state)
+	}
+	for lockOwner, count := range blockedByLock {
+		ch <- prometheus.MustNewConstMetric(c.blockedByLock, prometheus.GaugeValue, float64(count), lockOwner)
+	}
+	for _, thread := range parseThreadInfos(threadDump.System.Deadlocks) {
+		ch <- prometheus.MustNewConstMetric(c.deadlocked, prometheus.GaugeValue, 1, threadPool(thread.Name), thread.Name)
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *ThreadsCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect threads metrics: %v", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *ThreadsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.threads
+	ch <- c.blockedByLock
+	ch <- c.deadlocked
+}
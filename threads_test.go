@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestThreadPool(t *testing.T) {
+	tests := map[string]string{
+		"qtp1989972246-17": "qtp",
+		"qtp1989972246-17-acceptor-0@5b3a4b2d-ServerConnector":   "qtp",
+		"searcherExecutor-7-thread-1-processing-x:products":      "searcherExecutor",
+		"updateExecutor-2-thread-3-processing-n:10.0.0.1:8983_s": "updateExecutor",
+		"recoveryExecutor-4-thread-1":                            "recoveryExecutor",
+		"commitScheduler-10-thread-1":                            "commitScheduler",
+		"pool-5-thread-1":                                        "pool",
+		"Lucene Merge Thread #0":                                 "Lucene Merge Thread",
+		"main-SendThread(zookeeper:2181)":                        "main-SendThread",
+		"Scheduler-1593590521":                                   "Scheduler",
+		"Finalizer":                                              "Finalizer",
+		"42":                                                     "other",
+	}
+
+	for name, want := range tests {
+		if got := threadPool(name); got != want {
+			t.Errorf("threadPool(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// Response of the Solr 8 ThreadDumpHandler, in which the lock owner of the
+// blocked threads is reported under lock-waiting.
+const threadDumpResponse = `{
+  "responseHeader":{
+    "status":0,
+    "QTime":5},
+  "system":{
+    "threadCount":{
+      "current":5,
+      "peak":5,
+      "daemon":3},
+    "deadlocks":[
+      "thread",{
+        "id":21,
+        "name":"searcherExecutor-7-thread-1",
+        "state":"BLOCKED",
+        "lock":"java.lang.Object@6d7b4f4c",
+        "lock-waiting":{
+          "name":"java.lang.Object@6d7b4f4c",
+          "owner":{
+            "name":"commitScheduler-10-thread-1",
+            "id":22}},
+        "monitors-locked":["java.lang.Object@1f8c2a3b"],
+        "cpuTime":"12.3456ms",
+        "userTime":"10.0000ms",
+        "stackTrace":["org.apache.solr.core.SolrCore.getSearcher(SolrCore.java:2100)",
+          "java.lang.Thread.run(Thread.java:748)"]},
+      "thread",{
+        "id":22,
+        "name":"commitScheduler-10-thread-1",
+        "state":"BLOCKED",
+        "lock":"java.lang.Object@1f8c2a3b",
+        "lock-waiting":{
+          "name":"java.lang.Object@1f8c2a3b",
+          "owner":{
+            "name":"searcherExecutor-7-thread-1",
+            "id":21}},
+        "monitors-locked":["java.lang.Object@6d7b4f4c"],
+        "cpuTime":"45.6789ms",
+        "userTime":"40.0000ms",
+        "stackTrace":["org.apache.solr.update.DirectUpdateHandler2.commit(DirectUpdateHandler2.java:650)",
+          "java.lang.Thread.run(Thread.java:748)"]}],
+    "threadDump":[
+      "thread",{
+        "id":15,
+        "name":"qtp1989972246-15",
+        "state":"RUNNABLE",
+        "native":true,
+        "cpuTime":"120.5000ms",
+        "userTime":"100.0000ms",
+        "stackTrace":["sun.nio.ch.EPollArrayWrapper.epollWait(Native Method)",
+          "java.lang.Thread.run(Thread.java:748)"]},
+      "thread",{
+        "id":16,
+        "name":"qtp1989972246-16",
+        "state":"BLOCKED",
+        "lock":"java.lang.Object@1f8c2a3b",
+        "lock-waiting":{
+          "name":"java.lang.Object@1f8c2a3b",
+          "owner":{
+            "name":"commitScheduler-10-thread-1",
+            "id":22}},
+        "cpuTime":"3.2000ms",
+        "userTime":"3.0000ms",
+        "stackTrace":["org.apache.solr.update.DirectUpdateHandler2.addDoc(DirectUpdateHandler2.java:250)",
+          "java.lang.Thread.run(Thread.java:748)"]},
+      "thread",{
+        "id":17,
+        "name":"qtp1989972246-17",
+        "state":"BLOCKED",
+        "lock":"java.lang.Object@1f8c2a3b",
+        "lock-waiting":{
+          "name":"java.lang.Object@1f8c2a3b",
+          "owner":{
+            "name":"commitScheduler-10-thread-1",
+            "id":22}},
+        "cpuTime":"2.1000ms",
+        "userTime":"2.0000ms",
+        "stackTrace":["org.apache.solr.update.DirectUpdateHandler2.addDoc(DirectUpdateHandler2.java:250)",
+          "java.lang.Thread.run(Thread.java:748)"]},
+      "thread",{
+        "id":18,
+        "name":"qtp1989972246-18",
+        "state":"WAITING",
+        "lock":"java.util.concurrent.locks.AbstractQueuedSynchronizer$ConditionObject@3c5a99da",
+        "lock-waiting":{
+          "name":"java.util.concurrent.locks.AbstractQueuedSynchronizer$ConditionObject@3c5a99da",
+          "owner":null},
+        "cpuTime":"0.5000ms",
+        "userTime":"0.0000ms",
+        "stackTrace":["sun.misc.Unsafe.park(Native Method)",
+          "java.lang.Thread.run(Thread.java:748)"]},
+      "thread",{
+        "id":22,
+        "name":"commitScheduler-10-thread-1",
+        "state":"TIMED_WAITING",
+        "cpuTime":"45.6789ms",
+        "userTime":"40.0000ms",
+        "stackTrace":["java.lang.Thread.sleep(Native Method)",
+          "java.lang.Thread.run(Thread.java:748)"]}]}}`
+
+func TestThreadsCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, threadDumpResponse)
+	}))
+	defer server.Close()
+
+	collector, err := NewThreadsCollector(*http.DefaultClient, server.URL+"/solr")
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	metrics := collectMetrics(collector)
+	want := map[string]float64{
+		"solr_threads{pool=qtp,state=RUNNABLE}":                                             1,
+		"solr_threads{pool=qtp,state=BLOCKED}":                                              2,
+		"solr_threads{pool=qtp,state=WAITING}":                                              1,
+		"solr_threads{pool=commitScheduler,state=TIMED_WAITING}":                            1,
+		"solr_threads_blocked_by_lock_owner{lock_owner=commitScheduler-10-thread-1}":        2,
+		"solr_threads_deadlocked{pool=searcherExecutor,thread=searcherExecutor-7-thread-1}": 1,
+		"solr_threads_deadlocked{pool=commitScheduler,thread=commitScheduler-10-thread-1}":  1,
+	}
+	if len(metrics) != len(want) {
+		t.Errorf("got %d metrics, want %d: %v", len(metrics), len(want), metrics)
+	}
+	for metric, value := range want {
+		m, ok := metrics[metric]
+		if !ok {
+			t.Errorf("missing metric %s", metric)
+			continue
+		}
+		if m.GetGauge().GetValue() != value {
+			t.Errorf("%s = %v, want %v", metric, m.GetGauge().GetValue(), value)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type shardKey struct {
+	collection string
+	shard      string
+}
+
+type replicaKey struct {
+	collection string
+	shard      string
+	replica    string
+}
+
+type stateTransition struct {
+	from string
+	to   string
+}
+
+// TopologyTracker remembers the cluster topology between two scrapes and
+// counts the changes which happened in between. The series of the collections,
+// shards and replicas which disappear are removed, the deletion of a
+// collection is only exported until the next observation.
+type TopologyTracker struct {
+	mutex sync.Mutex
+
+	initialized   bool
+	collections   map[string]bool
+	deleted       map[string]bool
+	shardLeaders  map[shardKey]string
+	replicaStates map[replicaKey]string
+	recoveryStart map[replicaKey]time.Time
+	transitions   map[replicaKey]map[stateTransition]bool
+
+	replicaTransitions *prometheus.CounterVec
+	leaderElections    *prometheus.CounterVec
+	collectionsCreated *prometheus.CounterVec
+	collectionsDeleted *prometheus.CounterVec
+	recoveryDuration   *prometheus.GaugeVec
+}
+
+// NewTopologyTracker returns an initialized TopologyTracker.
+func NewTopologyTracker() *TopologyTracker {
+	return &TopologyTracker{
+		collections:   map[string]bool{},
+		deleted:       map[string]bool{},
+		shardLeaders:  map[shardKey]string{},
+		replicaStates: map[replicaKey]string{},
+		recoveryStart: map[replicaKey]time.Time{},
+		transitions:   map[replicaKey]map[stateTransition]bool{},
+
+		replicaTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cluster",
+			Name:      "replica_state_transitions_total",
+			Help:      "Number of observed replica state transitions.",
+		}, []string{"collection", "shard", "replica", "from", "to"}),
+		leaderElections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cluster",
+			Name:      "shard_leader_elections_total",
+			Help:      "Number of observed shard leader changes.",
+		}, []string{"collection", "shard"}),
+		collectionsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cluster",
+			Name:      "collections_created_total",
+			Help:      "Number of observed collection creations.",
+		}, []string{"collection"}),
+		collectionsDeleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cluster",
+			Name:      "collections_deleted_total",
+			Help:      "Number of observed collection deletions.",
+		}, []string{"collection"}),
+		recoveryDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "cluster",
+			Name:      "replica_recovery_duration_seconds",
+			Help:      "Time the replica has been observed in recovery.",
+		}, []string{"collection", "shard", "replica"}),
+	}
+}
+
+func isRecovering(state string) bool {
+	return state == "recovering" || state == "recovery_failed"
+}
+
+// Observe compares the given cluster status with the previously observed one.
+// Nothing is counted on the first observation as there is nothing to compare with.
+func (t *TopologyTracker) Observe(clusterStatus *ClusterStatus, now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	collections := map[string]bool{}
+	shardLeaders := map[shardKey]string{}
+	replicaStates := map[replicaKey]string{}
+	recoveryStart := map[replicaKey]time.Time{}
+
+	t.recoveryDuration.Reset()
+
+	for collectionName, collection := range clusterStatus.Cluster.Collections {
+		collections[collectionName] = true
+		if t.initialized && !t.collections[collectionName] {
+			t.collectionsCreated.WithLabelValues(collectionName).Inc()
+		}
+
+		for shardName, shard := range collection.Shards {
+			sk := shardKey{collectionName, shardName}
+			leader := ""
+			for replicaName, replica := range shard.Replicas {
+				if replica.IsLeader() {
+					leader = replicaName
+				}
+
+				rk := replicaKey{collectionName, shardName, replicaName}
+				replicaStates[rk] = replica.State
+				if previous, ok := t.replicaStates[rk]; ok && previous != replica.State {
+					t.replicaTransitions.WithLabelValues(collectionName, shardName, replicaName, previous, replica.State).Inc()
+					if t.transitions[rk] == nil {
+						t.transitions[rk] = map[stateTransition]bool{}
+					}
+					t.transitions[rk][stateTransition{previous, replica.State}] = true
+				}
+
+				if isRecovering(replica.State) {
+					start, ok := t.recoveryStart[rk]
+					if !ok {
+						start = now
+					}
+					recoveryStart[rk] = start
+					t.recoveryDuration.WithLabelValues(collectionName, shardName, replicaName).Set(now.Sub(start).Seconds())
+				}
+			}
+
+			shardLeaders[sk] = leader
+			if previous, ok := t.shardLeaders[sk]; ok && leader != "" && previous != leader {
+				t.leaderElections.WithLabelValues(collectionName, shardName).Inc()
+			}
+		}
+	}
+
+	deleted := map[string]bool{}
+	if t.initialized {
+		for collectionName := range t.collections {
+			if !collections[collectionName] {
+				t.collectionsDeleted.WithLabelValues(collectionName).Inc()
+				t.collectionsCreated.DeleteLabelValues(collectionName)
+				deleted[collectionName] = true
+			}
+		}
+	}
+	for collectionName := range t.deleted {
+		if !deleted[collectionName] {
+			t.collectionsDeleted.DeleteLabelValues(collectionName)
+		}
+	}
+	for sk := range t.shardLeaders {
+		if _, ok := shardLeaders[sk]; !ok {
+			t.leaderElections.DeleteLabelValues(sk.collection, sk.shard)
+		}
+	}
+	for rk, transitions := range t.transitions {
+		if _, ok := replicaStates[rk]; ok {
+			continue
+		}
+		for transition := range transitions {
+			t.replicaTransitions.DeleteLabelValues(rk.collection, rk.shard, rk.replica, transition.from, transition.to)
+		}
+		delete(t.transitions, rk)
+	}
+
+	t.initialized = true
+	t.collections = collections
+	t.deleted = deleted
+	t.shardLeaders = shardLeaders
+	t.replicaStates = replicaStates
+	t.recoveryStart = recoveryStart
+}
+
+// Collect implements the prometheus.Collector interface.
+func (t *TopologyTracker) Collect(ch chan<- prometheus.Metric) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.replicaTransitions.Collect(ch)
+	t.leaderElections.Collect(ch)
+	t.collectionsCreated.Collect(ch)
+	t.collectionsDeleted.Collect(ch)
+	t.recoveryDuration.Collect(ch)
+}
+
+// Describe implements the prometheus.Collector interface.
+func (t *TopologyTracker) Describe(ch chan<- *prometheus.Desc) {
+	t.replicaTransitions.Describe(ch)
+	t.leaderElections.Describe(ch)
+	t.collectionsCreated.Describe(ch)
+	t.collectionsDeleted.Describe(ch)
+	t.recoveryDuration.Describe(ch)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func newClusterStatus(collections map[string]map[string]ClusterReplica) *ClusterStatus {
+	clusterStatus := &ClusterStatus{}
+	clusterStatus.Cluster.Collections = map[string]ClusterCollection{}
+	for name, replicas := range collections {
+		clusterStatus.Cluster.Collections[name] = ClusterCollection{
+			Shards: map[string]ClusterShard{"shard1": {State: "active", Replicas: replicas}},
+		}
+	}
+	return clusterStatus
+}
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	if err := c.Write(m); err != nil {
+		t.Fatalf("writing counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	m := &dto.Metric{}
+	if err := g.Write(m); err != nil {
+		t.Fatalf("writing gauge: %v", err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestTopologyTracker_Observe(t *testing.T) {
+	tracker := NewTopologyTracker()
+	now := time.Unix(1000, 0)
+
+	tracker.Observe(newClusterStatus(map[string]map[string]ClusterReplica{
+		"products": {
+			"core_node1": {State: "active", Leader: "true"},
+			"core_node2": {State: "active"},
+		},
+		"logs": {
+			"core_node1": {State: "active", Leader: "true"},
+		},
+	}), now)
+
+	tracker.Observe(newClusterStatus(map[string]map[string]ClusterReplica{
+		"products": {
+			"core_node1": {State: "down"},
+			"core_node2": {State: "recovering", Leader: "true"},
+		},
+		"orders": {
+			"core_node1": {State: "active", Leader: "true"},
+		},
+	}), now.Add(10*time.Second))
+
+	tracker.Observe(newClusterStatus(map[string]map[string]ClusterReplica{
+		"products": {
+			"core_node1": {State: "down"},
+			"core_node2": {State: "recovering", Leader: "true"},
+		},
+		"orders": {
+			"core_node1": {State: "active", Leader: "true"},
+		},
+	}), now.Add(40*time.Second))
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"transition active to down", counterValue(t, tracker.replicaTransitions.WithLabelValues("products", "shard1", "core_node1", "active", "down")), 1},
+		{"transition active to recovering", counterValue(t, tracker.replicaTransitions.WithLabelValues("products", "shard1", "core_node2", "active", "recovering")), 1},
+		{"leader elections", counterValue(t, tracker.leaderElections.WithLabelValues("products", "shard1")), 1},
+		{"unchanged leader", counterValue(t, tracker.leaderElections.WithLabelValues("orders", "shard1")), 0},
+		{"collections created", counterValue(t, tracker.collectionsCreated.WithLabelValues("orders")), 1},
+		{"recovery duration", gaugeValue(t, tracker.recoveryDuration.WithLabelValues("products", "shard1", "core_node2")), 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopologyTracker_ObserveRemovals(t *testing.T) {
+	tracker := NewTopologyTracker()
+	now := time.Unix(1000, 0)
+
+	observations := []map[string]map[string]ClusterReplica{
+		{
+			"products": {"core_node1": {State: "active", Leader: "true"}, "core_node2": {State: "active"}},
+			"logs":     {"core_node1": {State: "active", Leader: "true"}},
+		},
+		{
+			"products": {"core_node1": {State: "down"}, "core_node2": {State: "active", Leader: "true"}},
+			"orders":   {"core_node1": {State: "active", Leader: "true"}},
+		},
+		// core_node1 is removed from products, logs was deleted in the previous observation
+		{
+			"products": {"core_node2": {State: "active", Leader: "true"}},
+			"orders":   {"core_node1": {State: "active", Leader: "true"}},
+		},
+		// products and orders are deleted
+		{},
+	}
+	tests := [][]struct {
+		name    string
+		present bool
+	}{
+		{
+			{"solr_cluster_collections_deleted_total{collection=logs}", false},
+		},
+		{
+			{"solr_cluster_replica_state_transitions_total{collection=products,from=active,replica=core_node1,shard=shard1,to=down}", true},
+			{"solr_cluster_shard_leader_elections_total{collection=products,shard=shard1}", true},
+			{"solr_cluster_collections_created_total{collection=orders}", true},
+			{"solr_cluster_collections_deleted_total{collection=logs}", true},
+		},
+		{
+			{"solr_cluster_replica_state_transitions_total{collection=products,from=active,replica=core_node1,shard=shard1,to=down}", false},
+			{"solr_cluster_shard_leader_elections_total{collection=products,shard=shard1}", true},
+			{"solr_cluster_collections_created_total{collection=orders}", true},
+			{"solr_cluster_collections_deleted_total{collection=logs}", false},
+		},
+		{
+			{"solr_cluster_shard_leader_elections_total{collection=products,shard=shard1}", false},
+			{"solr_cluster_collections_created_total{collection=orders}", false},
+			{"solr_cluster_collections_deleted_total{collection=orders}", true},
+			{"solr_cluster_collections_deleted_total{collection=products}", true},
+		},
+	}
+
+	for i, observation := range observations {
+		tracker.Observe(newClusterStatus(observation), now.Add(time.Duration(i)*10*time.Second))
+		metrics := collectMetrics(tracker)
+		for _, tt := range tests[i] {
+			if _, ok := metrics[tt.name]; ok != tt.present {
+				t.Errorf("observation %d: %s present = %v, want %v", i, tt.name, ok, tt.present)
+			}
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var zookeeperZnodePath = "/admin/zookeeper?detail=true&wt=json&path=%s"
+
+// Znodes checked on every scrape, per collection state.json and configset files are discovered.
+var zookeeperZnodes = []string{
+	"/clusterstate.json",
+	"/live_nodes",
+	"/overseer/queue",
+	"/overseer/queue-work",
+	"/overseer/collection-queue-work",
+}
+
+// ZnodeCollector collects the size of the znodes solr relies on
+type ZnodeCollector struct {
+	sizeBytes      *prometheus.Desc
+	children       *prometheus.Desc
+	maxBufferRatio *prometheus.Desc
+	oversized      *prometheus.Desc
+	warningRatio   *prometheus.Desc
+
+	client          http.Client
+	znodeURL        string
+	juteMaxBuffer   int64
+	warnRatio       float64
+	configsInterval time.Duration
+
+	mutex           sync.Mutex
+	lastConfigsWalk time.Time
+	configFiles     map[string]*ZookeeperZnode
+}
+
+// NewZnodeCollector returns a new Collector exposing the size of key zookeeper
+// znodes, the configset files are only walked once per configsInterval.
+func NewZnodeCollector(client http.Client, solrBaseURL string, juteMaxBuffer int64, warnRatio float64, configsInterval time.Duration) (*ZnodeCollector, error) {
+	znodeURL := fmt.Sprintf("%s%s", solrBaseURL, zookeeperZnodePath)
+	return &ZnodeCollector{
+		sizeBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "znode_size_bytes"),
+			"Size of the znode data in bytes.",
+			[]string{"path"},
+			nil,
+		),
+		children: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "znode_children"),
+			"Number of children of the znode.",
+			[]string{"path"},
+			nil,
+		),
+		maxBufferRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "znode_max_buffer_ratio"),
+			"Ratio between the znode data size and jute.maxbuffer.",
+			[]string{"path"},
+			nil,
+		),
+		oversized: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "znode_oversized"),
+			"Whether the znode max buffer ratio reached the warning ratio.",
+			[]string{"path"},
+			nil,
+		),
+		warningRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "znode_warning_ratio"),
+			"Configured jute.maxbuffer ratio above which a znode is considered oversized.",
+			[]string{},
+			nil,
+		),
+		client:          client,
+		znodeURL:        znodeURL,
+		juteMaxBuffer:   juteMaxBuffer,
+		warnRatio:       warnRatio,
+		configsInterval: configsInterval,
+		configFiles:     map[string]*ZookeeperZnode{},
+	}, nil
+}
+
+func (c *ZnodeCollector) getZnode(path string) (*ZookeeperZnode, error) {
+	znode := &ZookeeperZnode{}
+	if err := getJSON(c.client, fmt.Sprintf(c.znodeURL, url.QueryEscape(path)), znode); err != nil {
+		return nil, err
+	}
+	return znode, nil
+}
+
+// getChildren returns the names of the children of the given znode.
+func (c *ZnodeCollector) getChildren(path string) ([]string, error) {
+	znode, err := c.getZnode(path)
+	if err != nil {
+		return nil, err
+	}
+	children := []string{}
+	for _, tree := range znode.Tree {
+		for _, child := range tree.Children {
+			children = append(children, child.Data.Title)
+		}
+	}
+	return children, nil
+}
+
+// Update exposes znode related metrics from solr.
+func (c *ZnodeCollector) Update(ch chan<- prometheus.Metric) error {
+	collections, err := c.getChildren("/collections")
+	if err != nil {
+		// Solr answers with a bad request when it is not running in SolrCloud mode
+		if hasStatusCode(err, http.StatusBadRequest) {
+			log.Debugf("Solr is not running in SolrCloud mode, skipping znodes")
+			return nil
+		}
+		return fmt.Errorf("Error while querying Solr for collections znode: %v", err)
+	}
+	paths := append([]string{}, zookeeperZnodes...)
+	for _, collection := range collections {
+		paths = append(paths, "/collections/"+collection+"/state.json")
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.warningRatio, prometheus.GaugeValue, c.warnRatio)
+	for _, path := range paths {
+		// Some znodes do not exist on every cluster, like clusterstate.json on
+		// clusters using per collection state.
+		znode, err := c.getZnode(path)
+		if hasStatusCode(err, http.StatusNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Errorf("Error while querying Solr for znode %s: %v", path, err)
+			continue
+		}
+		if znode.Znode.Path == "" {
+			continue
+		}
+		c.collectZnode(ch, path, znode)
+	}
+	for path, znode := range c.getConfigFiles() {
+		c.collectZnode(ch, path, znode)
+	}
+
+	return nil
+}
+
+// getConfigFiles returns the files of the configsets. As it takes a request
+// per file, the configsets are only walked once per interval, even when the
+// walk fails, the last files being returned in between.
+func (c *ZnodeCollector) getConfigFiles() map[string]*ZookeeperZnode {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if time.Since(c.lastConfigsWalk) < c.configsInterval {
+		return c.configFiles
+	}
+	c.lastConfigsWalk = time.Now()
+
+	configs, err := c.getChildren("/configs")
+	if err != nil {
+		log.Errorf("Error while querying Solr for configs znode: %v", err)
+		return c.configFiles
+	}
+	files := map[string]*ZookeeperZnode{}
+	for _, config := range configs {
+		c.walkConfigFiles("/configs/"+config, files)
+	}
+	c.configFiles = files
+	return files
+}
+
+// walkConfigFiles adds the files under the given configset directory. The
+// configset znodes are directories without data, the files underneath like
+// managed-schema or large synonyms are the ones reaching jute.maxbuffer.
+func (c *ZnodeCollector) walkConfigFiles(dir string, files map[string]*ZookeeperZnode) {
+	children, err := c.getChildren(dir)
+	if err != nil {
+		log.Errorf("Error while querying Solr for znode %s: %v", dir, err)
+		return
+	}
+	for _, child := range children {
+		path := dir + "/" + child
+		znode, err := c.getZnode(path)
+		if err != nil {
+			log.Errorf("Error while querying Solr for znode %s: %v", path, err)
+			continue
+		}
+		switch {
+		case znode.Znode.Prop.ChildrenCount > 0:
+			c.walkConfigFiles(path, files)
+		// Empty directories and files, far from jute.maxbuffer
+		case znode.Znode.Prop.DataLength == 0:
+		default:
+			files[path] = znode
+		}
+	}
+}
+
+func (c *ZnodeCollector) collectZnode(ch chan<- prometheus.Metric, path string, znode *ZookeeperZnode) {
+	ratio := float64(znode.Znode.Prop.DataLength) / float64(c.juteMaxBuffer)
+	ch <- prometheus.MustNewConstMetric(c.sizeBytes, prometheus.GaugeValue, float64(znode.Znode.Prop.DataLength), path)
+	ch <- prometheus.MustNewConstMetric(c.children, prometheus.GaugeValue, float64(znode.Znode.Prop.ChildrenCount), path)
+	ch <- prometheus.MustNewConstMetric(c.maxBufferRatio, prometheus.GaugeValue, ratio, path)
+	ch <- prometheus.MustNewConstMetric(c.oversized, prometheus.GaugeValue, boolToFloat64(ratio >= c.warnRatio), path)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *ZnodeCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect znode metrics: %v", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *ZnodeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sizeBytes
+	ch <- c.children
+	ch <- c.maxBufferRatio
+	ch <- c.oversized
+	ch <- c.warningRatio
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeZnode struct {
+	children   []string
+	dataLength int
+}
+
+// startFakeZnodes serves the given znodes on the zookeeper admin handler and
+// counts the requests.
+func startFakeZnodes(znodes map[string]fakeZnode) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		path := r.URL.Query().Get("path")
+		znode, ok := znodes[path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		children := []string{}
+		for _, child := range znode.children {
+			children = append(children, fmt.Sprintf(`{"data": {"title": %q}}`, child))
+		}
+		fmt.Fprintf(w, `{"znode": {"path": %q, "prop": {"dataLength": %d, "children_count": %d}}, "tree": [{"data": {"title": %q}, "children": [%s]}]}`,
+			path, znode.dataLength, len(znode.children), path, strings.Join(children, ","))
+	}))
+	return server, &requests
+}
+
+func TestZnodeCollector(t *testing.T) {
+	server, requests := startFakeZnodes(map[string]fakeZnode{
+		"/collections":                            {children: []string{"products"}},
+		"/collections/products/state.json":        {dataLength: 2048},
+		"/live_nodes":                             {children: []string{"10.0.0.1:8983_solr"}},
+		"/configs":                                {children: []string{"products"}},
+		"/configs/products":                       {children: []string{"managed-schema", "lang", "empty"}},
+		"/configs/products/managed-schema":        {dataLength: 900000},
+		"/configs/products/lang":                  {children: []string{"stopwords_en.txt"}},
+		"/configs/products/lang/stopwords_en.txt": {dataLength: 1024},
+		"/configs/products/empty":                 {},
+	})
+	defer server.Close()
+
+	collector, err := NewZnodeCollector(*http.DefaultClient, server.URL+"/solr", 1000000, 0.8, time.Hour)
+	if err != nil {
+		t.Fatalf("creating collector: %v", err)
+	}
+
+	metrics := collectMetrics(collector)
+	for metric, want := range map[string]float64{
+		"solr_zookeeper_znode_size_bytes{path=/collections/products/state.json}":        2048,
+		"solr_zookeeper_znode_children{path=/live_nodes}":                               1,
+		"solr_zookeeper_znode_size_bytes{path=/configs/products/managed-schema}":        900000,
+		"solr_zookeeper_znode_oversized{path=/configs/products/managed-schema}":         1,
+		"solr_zookeeper_znode_size_bytes{path=/configs/products/lang/stopwords_en.txt}": 1024,
+		"solr_zookeeper_znode_oversized{path=/configs/products/lang/stopwords_en.txt}":  0,
+	} {
+		if got := metrics[metric].GetGauge().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", metric, got, want)
+		}
+	}
+	for _, path := range []string{"/configs/products", "/configs/products/lang", "/configs/products/empty"} {
+		if _, ok := metrics["solr_zookeeper_znode_size_bytes{path="+path+"}"]; ok {
+			t.Errorf("directory %s exported as a file", path)
+		}
+	}
+
+	// The configsets are not walked again before the interval
+	first := *requests
+	metrics = collectMetrics(collector)
+	if *requests-first >= first {
+		t.Errorf("second scrape made %d requests, the first %d", *requests-first, first)
+	}
+	if got := metrics["solr_zookeeper_znode_size_bytes{path=/configs/products/managed-schema}"].GetGauge().GetValue(); got != 900000 {
+		t.Errorf("cached managed-schema size = %v, want 900000", got)
+	}
+}
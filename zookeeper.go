@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/blang/semver"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var zookeeperStatusPath = "/admin/zookeeper/status?wt=json"
+
+var zookeeperEnsembleStatuses = []string{"green", "yellow", "orange", "red"}
+
+// The zookeeper status API appeared in solr 7.5
+var semanticVersionSolr75 = semver.MustParse("7.5.0")
+
+// ZookeeperCollector collects zookeeper ensemble metrics as seen by solr
+type ZookeeperCollector struct {
+	ensembleSize        *prometheus.Desc
+	ensembleMode        *prometheus.Desc
+	ensembleStatus      *prometheus.Desc
+	serverOk            *prometheus.Desc
+	serverState         *prometheus.Desc
+	outstandingRequests *prometheus.Desc
+	avgLatency          *prometheus.Desc
+	maxLatency          *prometheus.Desc
+	znodeCount          *prometheus.Desc
+	watchCount          *prometheus.Desc
+	connections         *prometheus.Desc
+
+	client             http.Client
+	zookeeperStatusURL string
+	solrInfoURL        string
+}
+
+// NewZookeeperCollector returns a new Collector exposing zookeeper statistics reported by solr.
+func NewZookeeperCollector(client http.Client, solrBaseURL string) (*ZookeeperCollector, error) {
+	zookeeperStatusURL := fmt.Sprintf("%s%s", solrBaseURL, zookeeperStatusPath)
+	solrInfoURL := fmt.Sprintf("%s%s", solrBaseURL, solrInfoPath)
+	return &ZookeeperCollector{
+		ensembleSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "ensemble_size"),
+			"Number of zookeeper servers configured in the ensemble.",
+			[]string{},
+			nil,
+		),
+		ensembleMode: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "ensemble_mode"),
+			"Zookeeper ensemble mode (ensemble or standalone), value is always 1.",
+			[]string{"mode"},
+			nil,
+		),
+		ensembleStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "ensemble_status"),
+			"Zookeeper ensemble status, 1 for the current status and 0 for the others.",
+			[]string{"status"},
+			nil,
+		),
+		serverOk: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "server_ok"),
+			"Whether the zookeeper server answered imok to ruok.",
+			[]string{"server"},
+			nil,
+		),
+		serverState: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "server_state"),
+			"Zookeeper server state (leader, follower, observer or standalone), value is always 1.",
+			[]string{"server", "state"},
+			nil,
+		),
+		outstandingRequests: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "server_outstanding_requests"),
+			"Number of queued requests on the zookeeper server.",
+			[]string{"server"},
+			nil,
+		),
+		avgLatency: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "server_avg_latency"),
+			"Average zookeeper server request latency in milliseconds.",
+			[]string{"server"},
+			nil,
+		),
+		maxLatency: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "server_max_latency"),
+			"Maximum zookeeper server request latency in milliseconds.",
+			[]string{"server"},
+			nil,
+		),
+		znodeCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "server_znode_count"),
+			"Number of znodes on the zookeeper server.",
+			[]string{"server"},
+			nil,
+		),
+		watchCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "server_watch_count"),
+			"Number of watches on the zookeeper server.",
+			[]string{"server"},
+			nil,
+		),
+		connections: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "zookeeper", "server_connections"),
+			"Number of alive connections to the zookeeper server.",
+			[]string{"server"},
+			nil,
+		),
+		client:             client,
+		zookeeperStatusURL: zookeeperStatusURL,
+		solrInfoURL:        solrInfoURL,
+	}, nil
+}
+
+// zookeeperDetail returns the numeric value of a zookeeper status detail, solr
+// forwards the raw mntr output so most of them are strings.
+func zookeeperDetail(details map[string]interface{}, key string) (float64, bool) {
+	switch value := details[key].(type) {
+	case float64:
+		return value, true
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Update exposes zookeeper related metrics from solr.
+func (c *ZookeeperCollector) Update(ch chan<- prometheus.Metric) error {
+	semanticVersion, err := getSolrVersion(c.client, c.solrInfoURL)
+	if err != nil {
+		return err
+	}
+	// No zookeeper status for solr < 7.5
+	if semanticVersion.LT(semanticVersionSolr75) {
+		return nil
+	}
+
+	zookeeperStatus := &ZookeeperStatus{}
+	if err := getJSON(c.client, c.zookeeperStatusURL, zookeeperStatus); err != nil {
+		// Solr answers with a bad request when it is not running in SolrCloud mode
+		if hasStatusCode(err, http.StatusBadRequest) {
+			log.Debugf("Solr is not running in SolrCloud mode, skipping zookeeper status")
+			return nil
+		}
+		return fmt.Errorf("Error while querying Solr for zookeeper status: %v", err)
+	}
+
+	status := zookeeperStatus.ZkStatus
+	ch <- prometheus.MustNewConstMetric(c.ensembleSize, prometheus.GaugeValue, float64(status.EnsembleSize))
+	ch <- prometheus.MustNewConstMetric(c.ensembleMode, prometheus.GaugeValue, 1, status.Mode)
+	for _, s := range zookeeperEnsembleStatuses {
+		ch <- prometheus.MustNewConstMetric(c.ensembleStatus, prometheus.GaugeValue, boolToFloat64(status.Status == s), s)
+	}
+
+	for _, details := range status.Details {
+		server, _ := details["host"].(string)
+		ok := fmt.Sprint(details["ok"]) == "true"
+		ch <- prometheus.MustNewConstMetric(c.serverOk, prometheus.GaugeValue, boolToFloat64(ok), server)
+
+		if state, found := details["zk_server_state"].(string); found {
+			ch <- prometheus.MustNewConstMetric(c.serverState, prometheus.GaugeValue, 1, server, state)
+		}
+
+		metrics := map[string]*prometheus.Desc{
+			"zk_outstanding_requests":  c.outstandingRequests,
+			"zk_avg_latency":           c.avgLatency,
+			"zk_max_latency":           c.maxLatency,
+			"zk_znode_count":           c.znodeCount,
+			"zk_watch_count":           c.watchCount,
+			"zk_num_alive_connections": c.connections,
+		}
+		for key, desc := range metrics {
+			if value, found := zookeeperDetail(details, key); found {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, server)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *ZookeeperCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.Update(ch); err != nil {
+		log.Errorf("Failed to collect zookeeper metrics: %v", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *ZookeeperCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.ensembleSize
+	ch <- c.ensembleMode
+	ch <- c.ensembleStatus
+	ch <- c.serverOk
+	ch <- c.serverState
+	ch <- c.outstandingRequests
+	ch <- c.avgLatency
+	ch <- c.maxLatency
+	ch <- c.znodeCount
+	ch <- c.watchCount
+	ch <- c.connections
+}
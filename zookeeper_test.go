@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// zookeeperStatusResponse is a /admin/zookeeper/status answer of a solr 7.5
+// cloud backed by a three servers ensemble with one of them down.
+const zookeeperStatusResponse = `{
+  "responseHeader": {"status": 0, "QTime": 12},
+  "zkStatus": {
+    "ensembleSize": 3,
+    "zkHost": "zk1:2181,zk2:2181,zk3:2181",
+    "mode": "ensemble",
+    "status": "yellow",
+    "details": [
+      {"host": "zk1:2181", "ok": true, "clientPort": "2181", "zk_server_state": "leader",
+       "zk_outstanding_requests": "0", "zk_avg_latency": "2", "zk_max_latency": "41",
+       "zk_znode_count": "312", "zk_watch_count": "57", "zk_num_alive_connections": "4"},
+      {"host": "zk2:2181", "ok": true, "clientPort": "2181", "zk_server_state": "follower",
+       "zk_outstanding_requests": "1", "zk_avg_latency": "3", "zk_max_latency": "12",
+       "zk_znode_count": "312", "zk_watch_count": "12", "zk_num_alive_connections": "2"},
+      {"host": "zk3:2181", "ok": false}
+    ]
+  }
+}`
+
+func startFakeSolrZookeeper(t *testing.T, version string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/solr/admin/info/system":
+			fmt.Fprintf(w, `{"lucene": {"solr-spec-version": %q}}`, version)
+		case "/solr/admin/zookeeper/status":
+			fmt.Fprint(w, zookeeperStatusResponse)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestZookeeperCollector(t *testing.T) {
+	server := startFakeSolrZookeeper(t, "7.5.0")
+	defer server.Close()
+
+	collector, _ := NewZookeeperCollector(*http.DefaultClient, server.URL+"/solr")
+	metrics := collectMetrics(collector)
+
+	tests := []struct {
+		metric string
+		want   float64
+	}{
+		{"solr_zookeeper_ensemble_size{}", 3},
+		{"solr_zookeeper_ensemble_mode{mode=ensemble}", 1},
+		{"solr_zookeeper_ensemble_status{status=yellow}", 1},
+		{"solr_zookeeper_ensemble_status{status=green}", 0},
+		{"solr_zookeeper_server_ok{server=zk1:2181}", 1},
+		{"solr_zookeeper_server_ok{server=zk3:2181}", 0},
+		{"solr_zookeeper_server_state{server=zk1:2181,state=leader}", 1},
+		{"solr_zookeeper_server_state{server=zk2:2181,state=follower}", 1},
+		{"solr_zookeeper_server_outstanding_requests{server=zk2:2181}", 1},
+		{"solr_zookeeper_server_avg_latency{server=zk2:2181}", 3},
+		{"solr_zookeeper_server_max_latency{server=zk1:2181}", 41},
+		{"solr_zookeeper_server_znode_count{server=zk1:2181}", 312},
+		{"solr_zookeeper_server_watch_count{server=zk1:2181}", 57},
+		{"solr_zookeeper_server_connections{server=zk1:2181}", 4},
+	}
+	for _, test := range tests {
+		m, ok := metrics[test.metric]
+		if !ok {
+			t.Errorf("missing metric %s", test.metric)
+			continue
+		}
+		if got := m.GetGauge().GetValue(); got != test.want {
+			t.Errorf("%s = %v, want %v", test.metric, got, test.want)
+		}
+	}
+	// A server which is down has no details
+	if _, ok := metrics["solr_zookeeper_server_avg_latency{server=zk3:2181}"]; ok {
+		t.Error("unexpected latency for the server which is down")
+	}
+}
+
+func TestZookeeperCollectorBeforeSolr75(t *testing.T) {
+	server := startFakeSolrZookeeper(t, "7.4.0")
+	defer server.Close()
+
+	collector, _ := NewZookeeperCollector(*http.DefaultClient, server.URL+"/solr")
+	if err := collector.Update(make(chan<- prometheus.Metric, 100)); err != nil {
+		t.Errorf("unexpected error for solr 7.4: %v", err)
+	}
+	if metrics := collectMetrics(collector); len(metrics) != 0 {
+		t.Errorf("got %d metrics for solr 7.4, want none", len(metrics))
+	}
+}